@@ -0,0 +1,222 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/view"
+)
+
+// Projections of the active cases that are backtested.
+const (
+	// constant spread rate, like .Forecast.Cases
+	rateProjection = "rate"
+
+	// spread rate varying at the rate of rates, like .Recovery.PeakCases
+	vcsProjection = "vcs"
+)
+
+var projections = []string{rateProjection, vcsProjection}
+
+func init() {
+	c := &backtestCmd{}
+	cmd := &cobra.Command{
+		Use:   "backtest [COUNTRY]",
+		Short: "Measures the accuracy of the projections of active cases in the selected COUNTRY",
+		Long: `Measures the accuracy of the projections of active cases in the selected COUNTRY,
+by replaying the status computation at every past day and comparing its projections with the actual active cases.
+
+The projections are at the constant spread rate ("rate", like the forecast of 'covid status')
+and at the spread rate varying at the rate of rates ("vcs", like the peak of 'covid status'),
+with every method to estimate the spread rates, unless --method is set.
+The status is computed on the last --days and --compareDays of every day, like 'covid status'.
+The errors are reported as mean absolute error (MAE) and mean absolute percentage error (MAPE) for every horizon.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().IntSliceVar(&c.horizons, "horizons", []int{1, 3, 7, 14}, "days after each past day to compare the projections with the actual cases")
+	cmd.Flags().Var(&c.from, "from", "first past day to replay with format: "+dateLayout+" (default is the first day with enough data)")
+	cmd.Flags().Var(&c.to, "to", "last past day to replay with format: "+dateLayout+" (default is the day before the latest)")
+	rootCmd.AddCommand(cmd)
+}
+
+type backtestCmd struct {
+	status   statusCmd
+	horizons []int
+	from, to date
+}
+
+type (
+	// projection of a model at a horizon, in days.
+	projection struct {
+		model   string
+		horizon int
+	}
+
+	// accuracy of the projections.
+	accuracy struct {
+		n, np       int
+		abs, absPct float64
+	}
+)
+
+func (c *backtestCmd) run(cmd *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	err = c.setRange()
+	if err != nil {
+		return err
+	}
+
+	methods := []method{twoPoint, ols, wls}
+	if cmd.Flags().Changed("method") {
+		methods = []method{c.status.method}
+	}
+
+	fmt.Printf("%s: backtest of the projections of %s from %s to %s\n",
+		strings.ToTitle(c.status.country), c.status.metric.noun(), c.from, c.to)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tMETHOD\tHORIZON\tFORECASTS\tMAE\tMAPE\t")
+	for _, m := range methods {
+		s := c.status
+		s.method = m
+		acc, err := backtest(s, c.from, c.to, c.horizons)
+		if err != nil {
+			return err
+		}
+		for _, model := range projections {
+			for _, h := range c.horizons {
+				a := acc[projection{model, h}]
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.0f\t%.1f%%\t\n", model, m, h, a.n, a.MAE(), a.MAPE()*100)
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// setRange of the past days to replay.
+func (c *backtestCmd) setRange() error {
+	if c.from.Time().IsZero() {
+		first, err := db.First()
+		if err != nil {
+			return err
+		}
+		c.from = date(first).AddDays(int(c.status.compareDays))
+	}
+	if c.to.Time().IsZero() {
+		c.to = c.status.now.AddDays(-1)
+	}
+	if c.to.Time().After(c.status.now.Time()) || c.from.Time().After(c.to.Time()) {
+		return fmt.Errorf("the days to replay should be from --from to --to, before %s", c.status.now)
+	}
+	return nil
+}
+
+/*
+backtest of the projections of a status computation (see statusCmd.fill),
+replayed at every day from a date to another, for each horizon in days
+that doesn't go after the latest data.
+Days with insufficient data are skipped.
+*/
+func backtest(s statusCmd, from, to date, horizons []int) (map[projection]*accuracy, error) {
+	acc := map[projection]*accuracy{}
+	for _, model := range projections {
+		for _, h := range horizons {
+			acc[projection{model, h}] = &accuracy{}
+		}
+	}
+
+	for t := from; !t.Time().After(to.Time()); t = t.AddDays(1) {
+		past := s.at(t)
+		v := &view.View{}
+		err := past.spread(v)
+		if err != nil {
+			return nil, err
+		}
+		if v.Status.InsufficientData {
+			continue
+		}
+		last, err := past.value(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range horizons {
+			if t.AddDays(h).Time().After(s.now.Time()) {
+				continue
+			}
+			actual, err := s.value(t.AddDays(h))
+			if err != nil {
+				return nil, err
+			}
+			f, err := calc.Forecast(last, v.Current.Rate, float64(h))
+			if err != nil {
+				return nil, err
+			}
+			acc[projection{rateProjection, h}].add(f, actual)
+			f, err = calc.Forecast3D(last, v.Current.Rate, v.Comparison.RateOfRates, float64(h))
+			if err != nil {
+				return nil, err
+			}
+			acc[projection{vcsProjection, h}].add(f, actual)
+		}
+	}
+	return acc, nil
+}
+
+// add a forecast, with the actual number.
+func (a *accuracy) add(forecast, actual float64) {
+	a.n++
+	a.abs += math.Abs(forecast - actual)
+	if actual > 0 {
+		a.np++
+		a.absPct += math.Abs(forecast-actual) / actual
+	}
+}
+
+// MAE is the mean absolute error, NaN without forecasts.
+func (a accuracy) MAE() float64 {
+	if a.n == 0 {
+		return math.NaN()
+	}
+	return a.abs / float64(a.n)
+}
+
+// MAPE is the mean absolute percentage error, as a fraction, NaN without forecasts of positive numbers.
+func (a accuracy) MAPE() float64 {
+	if a.np == 0 {
+		return math.NaN()
+	}
+	return a.absPct / float64(a.np)
+}
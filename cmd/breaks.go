@@ -0,0 +1,88 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	c := &breaksCmd{}
+	cmd := &cobra.Command{
+		Use:   "breaks [COUNTRY]",
+		Short: "Prints the breaks of the growth rate of the cases in the selected COUNTRY",
+		Long: `Prints the breaks of the growth rate of the cases in the selected COUNTRY,
+e.g. after important decisions, as segments of days with their own growth rate.
+
+The breaks are found by segmented regression of the logarithms of the cases of --metric (active cases by default),
+in segments of at least --segmentDays.
+The most recent break can be used as comparison date in 'covid status' with --compareBreak.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().IntVar(&c.segmentDays, "segmentDays", breakDays, "shortest segment, in days")
+	rootCmd.AddCommand(cmd)
+}
+
+type breaksCmd struct {
+	status      statusCmd
+	segmentDays int
+}
+
+func (c *breaksCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	from, segments, err := c.status.breaks(c.segmentDays)
+	if insufficient(err) {
+		return fmt.Errorf("insufficient data to find the breaks of %s", c.status.country)
+	} else if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: segments of the growth rate of %s, of at least %d days\n",
+		strings.ToTitle(c.status.country), c.status.metric.noun(), c.segmentDays)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tDAYS\tRATE\t")
+	for _, s := range segments {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.3f\t\n", from.AddDays(s.From), from.AddDays(s.To), s.To-s.From+1, s.Rate)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if len(segments) > 1 {
+		last := from.AddDays(segments[len(segments)-1].From)
+		fmt.Printf("\nThe most recent break is on %s: compare with --compareSince %s, or with --compareBreak.\n", last, last)
+	}
+	return nil
+}
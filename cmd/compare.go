@@ -0,0 +1,211 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+func init() {
+	c := &compareCmd{}
+	cmd := &cobra.Command{
+		Use:   "compare COUNTRY COUNTRY...",
+		Short: "Compares the status of the selected countries side by side",
+		Long: `Compares the status of the selected countries side by side, computed like 'covid status' for each of them,
+followed by the trajectories of their active cases over the last --trajectory days.
+
+With --align N, the trajectories are aligned by the days since each country reached N confirmed cases,
+instead of by calendar dates.
+Each COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MinimumNArgs(2),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().IntVar(&c.trajectory, "trajectory", 14, "days of the trajectories of active cases")
+	cmd.Flags().IntVar(&c.align, "align", 0, "align the trajectories by the days since the Nth confirmed case")
+	rootCmd.AddCommand(cmd)
+}
+
+type compareCmd struct {
+	status            statusCmd
+	trajectory, align int
+}
+
+func (c *compareCmd) run(_ *cobra.Command, args []string) error {
+	if c.trajectory < 1 || c.align < 0 {
+		return fmt.Errorf("--trajectory should be positive and --align not negative")
+	}
+	err := c.status.setDates()
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]statusCmd, len(args))
+	views := make([]*view.View, len(args))
+	for i, country := range args {
+		statuses[i] = c.status
+		statuses[i].setCountry(country)
+		views[i] = &view.View{}
+		if err := statuses[i].spread(views[i]); err != nil {
+			return err
+		}
+	}
+
+	p := message.NewPrinter(message.MatchLanguage("en"))
+	cases := c.status.metric.heading()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	row := func(name string, cell func(v *view.View) string) {
+		fmt.Fprint(w, name, "\t")
+		for _, v := range views {
+			if v.Status.InsufficientData && name != cases {
+				fmt.Fprint(w, "-\t")
+				continue
+			}
+			fmt.Fprint(w, cell(v), "\t")
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprint(w, "\t")
+	for _, v := range views {
+		fmt.Fprint(w, v.Country, "\t")
+	}
+	fmt.Fprintln(w)
+	row("SCORE", func(v *view.View) string { return p.Sprint(v.Status.Score) })
+	row("STATUS", func(v *view.View) string { return view.Label(v.Status.Score) })
+	row("RATE", func(v *view.View) string { return p.Sprintf("%.2f", v.Current.Rate) })
+	row("COMPARISON RATE", func(v *view.View) string { return p.Sprintf("%.2f", v.Comparison.Rate) })
+	row("DIM FACTOR", func(v *view.View) string { return p.Sprintf("%.3f", v.Comparison.RateOfRates) })
+	row(cases, func(v *view.View) string { return p.Sprint(v.Current.Cases) })
+	row(p.Sprintf("FORECAST %d DAYS", fcastDays), func(v *view.View) string { return p.Sprintf("%.0f", v.Forecast.Cases) })
+	row("DAYS TO PEAK", func(v *view.View) string { return recovery(p, v.Recovery.DaysToPeak, v) })
+	row("PEAK", func(v *view.View) string { return recovery(p, v.Recovery.PeakCases, v) })
+	row("DAYS TO 1 CASE", func(v *view.View) string {
+		if !v.Status.Resolving {
+			return "-"
+		}
+		return p.Sprintf("%.0f", v.Recovery.DaysTo1)
+	})
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	if c.align > 0 {
+		return c.aligned(p, statuses, views)
+	}
+	return c.calendar(p, statuses, views)
+}
+
+// calendar trajectories of the active cases, by date.
+func (c *compareCmd) calendar(p *message.Printer, statuses []statusCmd, views []*view.View) error {
+	from := c.status.now.AddDays(1 - c.trajectory)
+	series := make([][]float64, len(statuses))
+	for i, s := range statuses {
+		var err error
+		series[i], err = s.series(from)
+		if err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "DATE\t")
+	for _, v := range views {
+		fmt.Fprint(w, v.Country, "\t")
+	}
+	fmt.Fprintln(w)
+	for d := 0; d < c.trajectory; d++ {
+		fmt.Fprint(w, from.AddDays(d), "\t")
+		for i := range statuses {
+			fmt.Fprint(w, p.Sprintf("%.0f", series[i][d]), "\t")
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+// aligned trajectories of the active cases, by the days since the Nth confirmed case.
+func (c *compareCmd) aligned(p *message.Printer, statuses []statusCmd, views []*view.View) error {
+	first, err := db.First()
+	if err != nil {
+		return err
+	}
+	series := make([][]float64, len(statuses))
+	for i, s := range statuses {
+		confirmed, err := db.Series("confirmed", s.location(), first, s.now.Time())
+		if err != nil {
+			return err
+		}
+		start := 0
+		for start < len(confirmed) && confirmed[start] < c.align {
+			start++
+		}
+		if start == len(confirmed) {
+			continue
+		}
+		series[i], err = s.series(date(first).AddDays(start))
+		if err != nil {
+			return err
+		}
+		if len(series[i]) > c.trajectory {
+			series[i] = series[i][:c.trajectory]
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, p.Sprintf("DAYS SINCE %d CASES\t", c.align))
+	for _, v := range views {
+		fmt.Fprint(w, v.Country, "\t")
+	}
+	fmt.Fprintln(w)
+	for d := 0; d < c.trajectory; d++ {
+		cells, any := make([]string, len(series)), false
+		for i := range series {
+			if d < len(series[i]) {
+				cells[i], any = p.Sprintf("%.0f", series[i][d]), true
+			}
+		}
+		if !any {
+			break
+		}
+		fmt.Fprint(w, d, "\t", strings.Join(cells, "\t"), "\t\n")
+	}
+	return w.Flush()
+}
+
+// recovery projection, if the situation is improving.
+func recovery(p *message.Printer, f float64, v *view.View) string {
+	if !v.Status.Improving || !view.Finite(f) {
+		return "-"
+	}
+	return p.Sprintf("%.0f", f)
+}
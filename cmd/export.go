@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+const formatGeoJSON = "geojson"
+
+func init() {
+	c := &exportCmd{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Exports the status of all countries and provinces",
+		Long: `Exports the status of all countries and provinces, to be loaded into other tools.
+
+Supported formats:
+  geojson  a FeatureCollection of points at the coordinates of the places,
+           with the status (score, label, rate, dim factor, active cases and forecast) as properties.`,
+		RunE: c.run,
+		Args: cobra.NoArgs,
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().StringVarP(&c.format, "format", "f", formatGeoJSON, "output format")
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", "output file (default is the standard output)")
+	rootCmd.AddCommand(cmd)
+}
+
+type exportCmd struct {
+	status         statusCmd
+	format, output string
+}
+
+type (
+	featureCollection struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}
+	feature struct {
+		Type       string     `json:"type"`
+		Geometry   geometry   `json:"geometry"`
+		Properties properties `json:"properties"`
+	}
+	geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	}
+	properties struct {
+		Location     string   `json:"location"`
+		Country      string   `json:"country"`
+		Province     string   `json:"province,omitempty"`
+		Updated      string   `json:"updated"`
+		Score        uint8    `json:"score,omitempty"`
+		Label        string   `json:"label"`
+		Rate         *float64 `json:"rate"`
+		DimFactor    *float64 `json:"dimFactor"`
+		Active       int      `json:"active"`
+		Estimated    bool     `json:"estimated,omitempty"`
+		Forecast     *float64 `json:"forecast"`
+		ForecastDays int      `json:"forecastDays"`
+	}
+)
+
+func (c *exportCmd) run(*cobra.Command, []string) error {
+	if c.format != formatGeoJSON {
+		return fmt.Errorf("unsupported format `%s`", c.format)
+	}
+
+	err := c.status.setDates()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if c.output != "" {
+		f, err := os.Create(c.output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	places, err := db.Places()
+	if err != nil {
+		return err
+	}
+
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	for _, p := range places {
+		s := c.status
+		s.setCountry(p.Location())
+		v := &view.View{}
+		if err := s.spread(v); err != nil {
+			return err
+		}
+		props := properties{
+			Location:     p.Location(),
+			Country:      p.Country,
+			Province:     p.Province,
+			Updated:      s.now.String(),
+			Active:       v.Current.Cases,
+			Estimated:    v.Current.Estimated,
+			ForecastDays: v.Forecast.Days,
+			Label:        view.Label(v.Status.Score),
+		}
+		if !v.Status.InsufficientData {
+			props.Score = v.Status.Score
+			props.Rate = number(v.Current.Rate)
+			props.DimFactor = number(v.Comparison.RateOfRates)
+			props.Forecast = number(v.Forecast.Cases)
+		}
+		fc.Features = append(fc.Features, feature{
+			Type:       "Feature",
+			Geometry:   geometry{Type: "Point", Coordinates: [2]float64{p.Long, p.Lat}},
+			Properties: props,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// number is nil if not finite, to be encoded as JSON null.
+func number(f float64) *float64 {
+	if !view.Finite(f) {
+		return nil
+	}
+	return &f
+}
@@ -0,0 +1,228 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/model"
+	"github.com/jsidew/covid/pkg/registry"
+	"github.com/jsidew/covid/pkg/view"
+)
+
+// Days of the projections of the models.
+const modelDays = 730
+
+type forecastModel string
+
+func init() {
+	c := &forecastCmd{model: forecastModel(model.SEIR)}
+	cmd := &cobra.Command{
+		Use:   "forecast [COUNTRY]",
+		Short: "Prints the forecast of a model of COVID-19 spread in the selected COUNTRY",
+		Long: `Prints the forecast of a model of COVID-19 spread in the selected COUNTRY,
+compared with the projection of the Virus Control Scale.
+
+The logistic and Gompertz curves are fitted to the confirmed cases since the first case,
+giving the final size and the inflection date of the wave, best for countries past their peak.
+The compartmental models, SIR and SEIR, are fitted to the active cases since the comparison date (see --compareDays),
+and need the population of the country: either set --population, use the bundled registry,
+or add the country to the registry file ` + registry.File + ` in the profile folder (~/.covid) or in the --data folder,
+with header "country,population,continent".
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Var(&c.model, "model", "model of the spread: sir, seir, logistic or gompertz")
+	cmd.Flags().IntVar(&c.population, "population", 0, "population of the country (default from the registry)")
+	cmd.Flags().Float64Var(&c.incubation, "incubation", 5.2, "mean days from infection to infectiousness, for seir")
+	cmd.Flags().Float64Var(&c.infectious, "infectious", 14, "mean days of a case being active, before recovery or death")
+	rootCmd.AddCommand(cmd)
+}
+
+type forecastCmd struct {
+	status     statusCmd
+	model      forecastModel
+	population int
+	incubation float64
+	infectious float64
+}
+
+func (c *forecastCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	curve := c.model == forecastModel(calc.Logistic) || c.model == forecastModel(calc.Gompertz)
+	if !curve && c.status.metric != activeMetric {
+		return fmt.Errorf("the %s model is fitted to the active cases, not to the %s of --metric", c.model, c.status.metric.noun())
+	}
+	if c.population == 0 {
+		c.population = reg.Population(c.status.location())
+	}
+	if c.population == 0 && !curve {
+		return fmt.Errorf("unknown population of %s: set --population or add it to the registry", c.status.country)
+	}
+	v := &view.View{}
+	err = c.status.spread(v)
+	if err != nil {
+		return err
+	}
+
+	p := message.NewPrinter(message.MatchLanguage("en"))
+	p.Printf("%s: ", v.Country)
+	if curve {
+		err = c.curve(p)
+	} else {
+		err = c.compartmental(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.Printf("VCS projection: ")
+	cases := c.status.metric.noun()
+	switch {
+	case v.Status.InsufficientData:
+		p.Printf("insufficient data to estimate the spread.\n")
+	case v.Status.Resolving:
+		p.Printf("resolving, only 1 %s left in %.0f days.\n", strings.TrimSuffix(cases, "s"), v.Recovery.DaysTo1)
+	case v.Status.Improving:
+		p.Printf("peak of %.0f %s in %.0f days.\n", v.Recovery.PeakCases, cases, v.Recovery.DaysToPeak)
+	default:
+		p.Printf("no peak, %.0f %s in %d days.\n", v.Forecast.Cases, cases, v.Forecast.Days)
+	}
+	return nil
+}
+
+// compartmental model fitted to the active cases, with its outcome.
+func (c *forecastCmd) compartmental(p *message.Printer) error {
+	s := &c.status
+	active, err := s.activeSeries(s.compare)
+	if err != nil {
+		return err
+	}
+	confirmed, err := db.Cases("confirmed", s.location(), s.compare.Time())
+	if err != nil {
+		return err
+	}
+	params := model.Params{
+		Kind:       model.Kind(c.model),
+		Population: float64(c.population),
+		Incubation: c.incubation,
+		Infectious: c.infectious,
+	}
+	fit, err := params.FitTo(active, float64(confirmed)-active[0])
+	if insufficient(err) {
+		p.Printf("insufficient data to fit the %s model.\n", strings.ToUpper(c.model.String()))
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	o := fit.Outcome(fit.Start, modelDays)
+	p.Printf("%s model fitted to the active cases of the last %d days (R0 %.2f, RMSE of logarithms %.3f).\n",
+		strings.ToUpper(c.model.String()), fit.Days-1, fit.R0(), fit.RMSE)
+	peak := s.compare.AddDays(o.PeakDay).Time().Format("2 Jan 2006")
+	if days := o.PeakDay - (fit.Days - 1); days > 0 {
+		p.Printf("Peak of %.0f active cases on %s (in %d days)", o.PeakCases, peak, days)
+	} else {
+		p.Printf("Peak of %.0f active cases passed on %s", o.PeakCases, peak)
+	}
+	p.Printf(", attack rate of %.1f%% of %d people.\n", o.AttackRate*100, c.population)
+	return nil
+}
+
+// curve fitted to the confirmed cases since the first case, with the final size and the end of the wave.
+func (c *forecastCmd) curve(p *message.Printer) error {
+	s := &c.status
+	first, err := db.First()
+	if err != nil {
+		return err
+	}
+	cases, err := db.Series("confirmed", s.location(), first, s.now.Time())
+	if err != nil {
+		return err
+	}
+	start := 0
+	for start < len(cases) && cases[start] == 0 {
+		start++
+	}
+	series := make([]float64, len(cases)-start)
+	for i := range series {
+		series[i] = float64(cases[start+i])
+	}
+
+	name := strings.Title(c.model.String())
+	fit, err := calc.FitCurve(calc.Curve(c.model), series)
+	if insufficient(err) || err == calc.ErrNoConvergence {
+		p.Printf("insufficient data to fit the %s curve.\n", name)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	from := date(first).AddDays(start)
+	p.Printf("%s curve fitted to the confirmed cases since %s (R² %.3f).\n",
+		name, from.Time().Format("2 Jan 2006"), fit.R2)
+	last := float64(len(series) - 1)
+	inflection := from.AddDays(int(math.Round(fit.T))).Time().Format("2 Jan 2006")
+	if days := math.Round(fit.T - last); days > 0 {
+		p.Printf("Final size of %.0f cases, inflection on %s (in %.0f days)", fit.K, inflection, days)
+	} else {
+		p.Printf("Final size of %.0f cases, inflection passed on %s", fit.K, inflection)
+	}
+	// end of the wave, with less than 1 new case a day
+	for t := math.Max(fit.T, last); t < last+modelDays; t++ {
+		if fit.At(t+1)-fit.At(t) < 1 {
+			p.Printf(", less than 1 new case a day in %.0f days", t+1-last)
+			break
+		}
+	}
+	p.Printf(".\n")
+	return nil
+}
+
+func (m forecastModel) String() string {
+	return string(m)
+}
+
+func (m forecastModel) Type() string {
+	return "model"
+}
+
+func (m *forecastModel) Set(s string) error {
+	switch n := forecastModel(strings.ToLower(strings.TrimSpace(s))); n {
+	case forecastModel(model.SIR), forecastModel(model.SEIR), forecastModel(calc.Logistic), forecastModel(calc.Gompertz):
+		*m = n
+		return nil
+	}
+	return fmt.Errorf("unknown model `%s`", s)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+func init() {
+	c := &historyCmd{}
+	cmd := &cobra.Command{
+		Use:   "history [COUNTRY]",
+		Short: "Prints the timeline of the status of the selected COUNTRY",
+		Long: `Prints the timeline of the status of the selected COUNTRY: score, spread rate and dim factor of every day,
+computed like 'covid status' was run on that day, on the last --days and --compareDays.
+
+The days when the score changed are highlighted with the previous score,
+and the days of the events of COUNTRY (see 'covid impact') are annotated with their labels.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Var(&c.from, "from", "first day of the timeline with format: "+dateLayout+" (default is 30 days before --to)")
+	cmd.Flags().Var(&c.to, "to", "last day of the timeline with format: "+dateLayout+" (default is the latest day with data)")
+	rootCmd.AddCommand(cmd)
+}
+
+type historyCmd struct {
+	status   statusCmd
+	from, to date
+}
+
+func (c *historyCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	if c.to.Time().IsZero() {
+		c.to = c.status.now
+	}
+	if c.from.Time().IsZero() {
+		c.from = c.to.AddDays(-30)
+	}
+	if c.to.Time().After(c.status.now.Time()) || c.from.Time().After(c.to.Time()) {
+		return fmt.Errorf("the timeline should be from --from to --to, not after %s", c.status.now)
+	}
+
+	fmt.Printf("%s: status from %s to %s, on the last %d and %d days (%s)\n",
+		strings.ToTitle(c.status.country), c.from, c.to, c.status.days, c.status.compareDays, c.status.methodName())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "DATE\tSCORE\tSTATUS\tRATE\tDIM FACTOR\t%s\tCHANGE\tEVENT\t\n", c.status.metric.heading())
+	annotations := evs.Of(c.status.location())
+	changes := 0
+	previous := uint8(0)
+	for t := c.from; !t.Time().After(c.to.Time()); t = t.AddDays(1) {
+		s := c.status.at(t)
+		v := &view.View{}
+		if err := s.spread(v); err != nil {
+			return err
+		}
+
+		change := ""
+		if !t.Time().Equal(c.from.Time()) && v.Status.Score != previous {
+			change = fmt.Sprintf("from %d (%s)", previous, view.Label(previous))
+			changes++
+		}
+		previous = v.Status.Score
+		event := strings.Join(annotations.On(t.Time()).Labels(), ", ")
+
+		if v.Status.InsufficientData {
+			fmt.Fprintf(w, "%s\t-\t%s\t-\t-\t%d\t%s\t%s\t\n", t, view.Label(0), v.Current.Cases, change, event)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%.3f\t%d\t%s\t%s\t\n", t, v.Status.Score, view.Label(v.Status.Score),
+			v.Current.Rate, v.Comparison.RateOfRates, v.Current.Cases, change, event)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	times := "times"
+	if changes == 1 {
+		times = "time"
+	}
+	fmt.Printf("\nThe score changed %d %s.\n", changes, times)
+	return nil
+}
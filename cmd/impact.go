@@ -0,0 +1,126 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/events"
+	"github.com/jsidew/covid/pkg/view"
+)
+
+func init() {
+	c := &impactCmd{}
+	cmd := &cobra.Command{
+		Use:   "impact COUNTRY [EVENT]",
+		Short: "Measures the impact of the events on the spread in the selected COUNTRY",
+		Long: `Measures the impact of the events (e.g. lockdowns) on the spread of active cases in the selected COUNTRY:
+the spread rate and dim factor before every event, computed like 'covid status' was run on the day of the event,
+are compared with the ones after, on the last --days from --lag days after the event,
+the time for the event to show in the reported cases.
+
+The events are read from the file ` + events.File + ` in the profile directory ~/.covid (or in --data),
+with header "country,date,label": EVENT selects the events with that label, otherwise all the events of COUNTRY are measured.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.RangeArgs(1, 2),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().IntVar(&c.lag, "lag", 7, "days after an event for its impact to show in the reported cases")
+	rootCmd.AddCommand(cmd)
+}
+
+type impactCmd struct {
+	status statusCmd
+	lag    int
+}
+
+func (c *impactCmd) run(_ *cobra.Command, args []string) error {
+	if c.lag < 0 {
+		return fmt.Errorf("--lag should not be negative")
+	}
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	list := evs.Of(c.status.location()).Until(c.status.now.Time())
+	if len(args) > 1 {
+		list = list.Labelled(args[1])
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no events of %s in %s", c.status.country, events.File)
+	}
+
+	fmt.Printf("%s: impact of the events after %d days, on the last %d and %d days (%s)\n",
+		strings.ToTitle(c.status.country), c.lag, c.status.days, c.status.compareDays, c.status.methodName())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tEVENT\tAFTER\tRATE BEFORE\tRATE AFTER\tCHANGE\tDIM FACTOR BEFORE\tDIM FACTOR AFTER\t")
+	for _, e := range list {
+		t := date(e.Date)
+		before := &view.View{}
+		s := c.status.at(t)
+		if err := s.spread(before); err != nil {
+			return err
+		}
+
+		after := &view.View{}
+		end := t.AddDays(c.lag + int(c.status.days))
+		if end.Time().After(c.status.now.Time()) {
+			// the days after the event are not all in the data yet
+			after.Status.InsufficientData = true
+		} else {
+			s = c.status.at(end)
+			if err := s.spread(after); err != nil {
+				return err
+			}
+		}
+
+		rate, dim := impacts(before, after)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", t, e.Label, end,
+			rate[0], rate[1], rate[2], dim[0], dim[1])
+	}
+	return w.Flush()
+}
+
+// impacts on the spread rate (before, after and change) and on the dim factor (before and after).
+func impacts(before, after *view.View) (rate [3]string, dim [2]string) {
+	rate = [3]string{"-", "-", "-"}
+	dim = [2]string{"-", "-"}
+	if !before.Status.InsufficientData {
+		rate[0] = fmt.Sprintf("%.2f", before.Current.Rate)
+		dim[0] = fmt.Sprintf("%.3f", before.Comparison.RateOfRates)
+	}
+	if !after.Status.InsufficientData {
+		rate[1] = fmt.Sprintf("%.2f", after.Current.Rate)
+		dim[1] = fmt.Sprintf("%.3f", after.Comparison.RateOfRates)
+	}
+	if !before.Status.InsufficientData && !after.Status.InsufficientData {
+		rate[2] = fmt.Sprintf("%+.2f", after.Current.Rate-before.Current.Rate)
+	}
+	return
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+
+	"github.com/jsidew/covid/pkg/calc"
+)
+
+func init() {
+	c := &metricsCmd{}
+	cmd := &cobra.Command{
+		Use:   "metrics [COUNTRY]",
+		Short: "Prints the new cases and deaths of every day of the selected COUNTRY",
+		Long: `Prints the new confirmed cases and deaths of every day of the selected COUNTRY,
+with their rolling averages of the last 7 days, the week-over-week change of the averages,
+and the case fatality ratio (CFR): the deaths over the confirmed cases --fatalityLag days before,
+the days from confirmation to death.
+
+The metrics of the latest day are also available to the templates of 'covid status' as .Metrics.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Var(&c.from, "from", "first day of the series with format: "+dateLayout+" (default is 14 days ago)")
+	rootCmd.AddCommand(cmd)
+}
+
+type metricsCmd struct {
+	status statusCmd
+	from   date
+}
+
+func (c *metricsCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	if c.from.Time().IsZero() {
+		c.from = c.status.now.AddDays(1 - 2*metricDays)
+	}
+	if c.from.Time().After(c.status.now.Time()) {
+		return fmt.Errorf("--from %s is after the last day with data %s", c.from, c.status.now)
+	}
+	first, err := db.First()
+	if err != nil {
+		return err
+	}
+	if c.from.Time().Before(first) {
+		return fmt.Errorf("--from %s is before the first day with data %s", c.from, date(first))
+	}
+
+	// the days of the two weeks before the first, for the averages and their changes
+	before := c.from.AddDays(-2 * metricDays)
+	cases, err := c.status.newCases(before)
+	if err != nil {
+		return err
+	}
+	deaths, err := c.status.newDeaths(before)
+	if err != nil {
+		return err
+	}
+	casesAvg, err := calc.RollingMean(cases, metricDays)
+	if err != nil {
+		return err
+	}
+	deathsAvg, err := calc.RollingMean(deaths, metricDays)
+	if err != nil {
+		return err
+	}
+
+	p := message.NewPrinter(message.MatchLanguage("en"))
+	fmt.Printf("%s: new cases and deaths, with averages of %d days, and deaths over the cases of %d days before\n",
+		strings.ToTitle(c.status.country), metricDays, c.status.fatalityLag)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tNEW CASES\tAVERAGE\tCHANGE\tNEW DEATHS\tAVERAGE\tCHANGE\tCFR\t")
+	for t := c.from; !t.Time().After(c.status.now.Time()); t = t.AddDays(1) {
+		i := t.DaysFrom(before)
+		j := i - metricDays + 1
+		cfr, err := c.status.caseFatality(t)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", t,
+			p.Sprintf("%.0f", cases[i]), p.Sprintf("%.0f", casesAvg[j]), percentChange(casesAvg[j-metricDays], casesAvg[j]),
+			p.Sprintf("%.0f", deaths[i]), p.Sprintf("%.1f", deathsAvg[j]), percentChange(deathsAvg[j-metricDays], deathsAvg[j]),
+			percent(cfr))
+	}
+	return w.Flush()
+}
+
+// percentChange from a number to the next, as a percentage.
+func percentChange(from, to float64) string {
+	if from <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%%", (to/from-1)*100)
+}
+
+func percent(f float64) string {
+	if math.IsNaN(f) {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", f*100)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+// distance units in km
+var units = map[string]float64{
+	"km": 1,
+	"m":  0.001,
+	"mi": 1.609344,
+}
+
+type (
+	distance float64 // km
+	coords   struct {
+		lat, long float64
+		set       bool
+	}
+)
+
+func init() {
+	c := &nearCmd{radius: 500}
+	cmd := &cobra.Command{
+		Use:   "near LAT,LON",
+		Short: "Lists countries and provinces near the coordinates, with their status",
+		Long: `Lists countries and provinces within a radius from the coordinates LAT,LON, with their status.
+
+Places are sorted by distance; for negative latitudes, separate the coordinates from the flags with "--"
+(e.g. covid near -r 300km -- -33.87,151.21).`,
+		RunE: c.run,
+		Args: cobra.ExactArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().VarP(&c.radius, "radius", "r", "distance from the coordinates, in km, m or mi (e.g. 300mi)")
+	rootCmd.AddCommand(cmd)
+}
+
+type nearCmd struct {
+	status statusCmd
+	radius distance
+}
+
+func (c *nearCmd) run(_ *cobra.Command, args []string) error {
+	var at coords
+	if err := at.Set(args[0]); err != nil {
+		return err
+	}
+
+	err := c.status.setDates()
+	if err != nil {
+		return err
+	}
+
+	places, err := db.Near(at.lat, at.long, float64(c.radius))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "LOCATION\tDISTANCE\tSCORE\tSTATUS\tRATE\tDIM FACTOR\t%s\t\n", c.status.metric.heading())
+	for _, p := range places {
+		s := c.status
+		s.setCountry(p.Location())
+		v := &view.View{}
+		if err := s.spread(v); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%.0fkm\t", p.Location(), p.Distance(at.lat, at.long))
+		if v.Status.InsufficientData {
+			fmt.Fprintf(w, "-\t%s\t-\t-\t%d\t\n", view.Label(0), v.Current.Cases)
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.2f\t%.3f\t%d\t\n",
+			v.Status.Score, view.Label(v.Status.Score), v.Current.Rate, v.Comparison.RateOfRates, v.Current.Cases)
+	}
+
+	return w.Flush()
+}
+
+func (d distance) String() string {
+	return strconv.FormatFloat(float64(d), 'f', -1, 64) + "km"
+}
+
+func (d distance) Type() string {
+	return "distance"
+}
+
+func (d *distance) Set(s string) error {
+	s = strings.ToLower(strings.TrimSpace(s))
+	unit := 1.0
+	for _, u := range []string{"km", "mi", "m"} {
+		if strings.HasSuffix(s, u) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u)), units[u]
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid distance `%s`", s)
+	}
+	*d = distance(f * unit)
+	return nil
+}
+
+func (c coords) String() string {
+	if !c.set {
+		return ""
+	}
+	return fmt.Sprintf("%g,%g", c.lat, c.long)
+}
+
+func (c coords) Type() string {
+	return "coords"
+}
+
+func (c *coords) Set(s string) error {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("coordinates `%s` should have format LAT,LON", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return fmt.Errorf("invalid latitude `%s`", parts[0])
+	}
+	long, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || long < -180 || long > 180 {
+		return fmt.Errorf("invalid longitude `%s`", parts[1])
+	}
+	*c = coords{lat, long, true}
+	return nil
+}
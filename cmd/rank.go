@@ -0,0 +1,279 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+const formatCSV = "csv"
+
+// Orders of the ranking, from the worst.
+var rankings = map[string]func(a, b ranked) bool{
+	"score": func(a, b ranked) bool {
+		return a.Score > b.Score || (a.Score == b.Score && greater(a.Rate, b.Rate))
+	},
+	"rate": func(a, b ranked) bool {
+		return greater(a.Rate, b.Rate)
+	},
+	"cases": func(a, b ranked) bool {
+		return a.Cases > b.Cases
+	},
+	"incidence": func(a, b ranked) bool {
+		return greater(a.Incidence, b.Incidence)
+	},
+}
+
+func init() {
+	c := &rankCmd{}
+	cmd := &cobra.Command{
+		Use:   "rank",
+		Short: "Ranks the countries from the worst status",
+		Long: `Ranks the countries from the worst status, computed like 'covid status' for each of them.
+
+The countries are sorted by --by:
+  score      the score of the VCS, then the spread rate (the default);
+  rate       the spread rate;
+  cases      the cases of the selected --metric (the active cases by default);
+  incidence  the cases of the selected --metric per 100,000 people, with the population from the registry (see 'covid forecast').
+The continents are from the registry too.`,
+		RunE: c.run,
+		Args: cobra.NoArgs,
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().StringVar(&c.by, "by", "score", "order of the ranking: score, rate, cases or incidence")
+	cmd.Flags().IntVar(&c.minCases, "min-cases", 0, "rank only the countries with at least n cases of the selected --metric")
+	cmd.Flags().StringVar(&c.continent, "continent", "", "rank only the countries of a continent")
+	cmd.Flags().IntVarP(&c.top, "top", "t", 0, "number of countries to print (default is all)")
+	cmd.Flags().StringVarP(&c.format, "format", "f", formatText, "output format: text, csv or json")
+	cmd.Flags().IntVarP(&c.workers, "workers", "w", runtime.NumCPU(), "number of countries computed concurrently")
+	rootCmd.AddCommand(cmd)
+}
+
+type rankCmd struct {
+	status                 statusCmd
+	by, continent, format  string
+	minCases, top, workers int
+}
+
+// rankResult of the computation of a country.
+type rankResult struct {
+	ranked
+	err error
+}
+
+type ranked struct {
+	Rank      int      `json:"rank"`
+	Location  string   `json:"location"`
+	Continent string   `json:"continent,omitempty"`
+	Score     uint8    `json:"score,omitempty"`
+	Label     string   `json:"label"`
+	Rate      *float64 `json:"rate"`
+	DimFactor *float64 `json:"dimFactor"`
+	Cases     int      `json:"cases"`
+	Incidence *float64 `json:"incidence"`
+}
+
+func (c *rankCmd) run(*cobra.Command, []string) error {
+	less, ok := rankings[c.by]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown ranking `%s`", c.by)
+	case c.format != formatText && c.format != formatCSV && c.format != formatJSON:
+		return fmt.Errorf("unsupported format `%s`", c.format)
+	case c.workers < 1:
+		return fmt.Errorf("--workers should be at least 1")
+	}
+	if c.continent != "" {
+		known := false
+		for _, name := range reg.Continents() {
+			known = known || strings.EqualFold(name, strings.TrimSpace(c.continent))
+		}
+		if !known {
+			return fmt.Errorf("unknown continent `%s`, should be one of: %s", c.continent, strings.Join(reg.Continents(), ", "))
+		}
+	}
+	err := c.status.setDates()
+	if err != nil {
+		return err
+	}
+
+	countries, err := db.Countries()
+	if err != nil {
+		return err
+	}
+	list := []ranked{}
+	for _, r := range c.compute(countries) {
+		if r.err != nil {
+			return r.err
+		}
+		if r.Cases < c.minCases || (c.continent != "" && !strings.EqualFold(r.Continent, strings.TrimSpace(c.continent))) {
+			continue
+		}
+		list = append(list, r.ranked)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return less(list[i], list[j])
+	})
+	for i := range list {
+		list[i].Rank = i + 1
+	}
+	if c.top > 0 && c.top < len(list) {
+		list = list[:c.top]
+	}
+
+	switch c.format {
+	case formatJSON:
+		return json.NewEncoder(os.Stdout).Encode(list)
+	case formatCSV:
+		return c.csv(list)
+	}
+	return c.table(list)
+}
+
+/*
+compute the status of the countries, concurrently by a pool of workers:
+the results are in the order of the countries.
+*/
+func (c *rankCmd) compute(countries []string) []rankResult {
+	results := make([]rankResult, len(countries))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].ranked, results[i].err = c.rank(countries[i])
+			}
+		}()
+	}
+	for i := range countries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// rank a country, with its status.
+func (c *rankCmd) rank(country string) (ranked, error) {
+	s := c.status
+	s.setCountry(country)
+	v := &view.View{}
+	if err := s.spread(v); err != nil {
+		return ranked{}, err
+	}
+	r := ranked{
+		Location:  country,
+		Continent: reg.Continent(country),
+		Label:     view.Label(v.Status.Score),
+		Cases:     v.Current.Cases,
+	}
+	if p := reg.Population(country); p > 0 {
+		r.Incidence = number(float64(v.Current.Cases) / float64(p) * 100000)
+	}
+	if !v.Status.InsufficientData {
+		r.Score = v.Status.Score
+		r.Rate = number(v.Current.Rate)
+		r.DimFactor = number(v.Comparison.RateOfRates)
+	}
+	return r, nil
+}
+
+func (c *rankCmd) table(list []ranked) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RANK\tLOCATION\tCONTINENT\tSCORE\tSTATUS\tRATE\tDIM FACTOR\t%s\tPER 100K\t\n", c.status.metric.heading())
+	for _, r := range list {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t\n", r.Rank, r.Location, or(r.Continent, "-"),
+			or(formatted("%d", r.Score, r.Score > 0), "-"), r.Label,
+			or(formatted("%.2f", value(r.Rate), r.Rate != nil), "-"),
+			or(formatted("%.3f", value(r.DimFactor), r.DimFactor != nil), "-"),
+			r.Cases, or(formatted("%.1f", value(r.Incidence), r.Incidence != nil), "-"))
+	}
+	return w.Flush()
+}
+
+func (c *rankCmd) csv(list []ranked) error {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{"rank", "location", "continent", "score", "label", "rate", "dimFactor", "cases", "incidence"})
+	if err != nil {
+		return err
+	}
+	for _, r := range list {
+		err := w.Write([]string{strconv.Itoa(r.Rank), r.Location, r.Continent,
+			formatted("%d", r.Score, r.Score > 0), r.Label,
+			formatted("%g", value(r.Rate), r.Rate != nil),
+			formatted("%g", value(r.DimFactor), r.DimFactor != nil),
+			strconv.Itoa(r.Cases), formatted("%g", value(r.Incidence), r.Incidence != nil)})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// value of a number, NaN if nil (see number).
+func value(f *float64) float64 {
+	if f == nil {
+		return math.NaN()
+	}
+	return *f
+}
+
+// greater tells whether a number is greater than another, with the missing numbers (nil) last.
+func greater(a, b *float64) bool {
+	return a != nil && (b == nil || *a > *b)
+}
+
+// formatted value, or empty if not ok.
+func formatted(layout string, v interface{}, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(layout, v)
+}
+
+// or a default string, for an empty one.
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
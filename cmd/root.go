@@ -33,6 +33,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/jsidew/covid/pkg/database"
+	"github.com/jsidew/covid/pkg/events"
+	"github.com/jsidew/covid/pkg/registry"
 )
 
 var version = "1.0.0-beta.1"
@@ -51,7 +53,10 @@ var (
 		Long:    `covid is a simple tool to undertand the COVID-19 current situation of countries around the world.`,
 	}
 
-	db *database.DB
+	db      *database.DB
+	reg     registry.Registry
+	evs     events.Events
+	dataDir string
 )
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -71,6 +76,7 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.covid.yaml)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data", "", "read the data from CSV files in the directory (e.g. generated with 'covid synth'), instead of the web")
 }
 
 func initConfig() {
@@ -81,10 +87,21 @@ func initConfig() {
 	err = os.MkdirAll(profile, os.ModeDir|0700)
 	exitif(err)
 
-	db = database.New(dbOrigin, profile, cacheExpire)
-	db.Set("confirmed", "/master/time_series_19-covid-Confirmed.csv")
-	db.Set("recovered", "/master/time_series_19-covid-Recovered.csv")
-	db.Set("dead", "/master/time_series_19-covid-Deaths.csv")
+	if dataDir != "" {
+		db = database.NewLocal(dataDir)
+		reg, err = registry.Load(filepath.Join(dataDir, registry.File))
+		exitif(err)
+		evs, err = events.Load(filepath.Join(dataDir, events.File))
+	} else {
+		db = database.New(dbOrigin, profile, cacheExpire)
+		reg, err = registry.Load(filepath.Join(profile, registry.File))
+		exitif(err)
+		evs, err = events.Load(filepath.Join(profile, events.File))
+	}
+	exitif(err)
+	db.Set("confirmed", "/master/time_series_19-covid-Confirmed.csv", database.Total)
+	db.Set("recovered", "/master/time_series_19-covid-Recovered.csv", database.Subtract)
+	db.Set("dead", "/master/time_series_19-covid-Deaths.csv", database.Subtract)
 }
 
 func exitif(err error) {
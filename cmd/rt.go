@@ -0,0 +1,143 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/calc"
+)
+
+func init() {
+	c := &rtCmd{}
+	cmd := &cobra.Command{
+		Use:   "rt [COUNTRY]",
+		Short: "Prints the reproduction number (Rt) of every day of the selected COUNTRY",
+		Long: `Prints the effective reproduction number (Rt) of every day of the selected COUNTRY, with its confidence interval:
+the average number of people infected by each infected person.
+
+Rt is estimated from the new confirmed cases (or the new deaths with --metric deaths) of the last --days of each day,
+with the method of Cori et al. (2013), or from their growth rate with --growth;
+the serial interval is a gamma distribution of mean --serialInterval and standard deviation --serialIntervalSD.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Var(&c.from, "from", "first day of the series with format: "+dateLayout+" (default is 30 days ago)")
+	cmd.Flags().BoolVarP(&c.growth, "growth", "g", false, "estimate Rt from the growth rate of the new cases, instead of the Cori method")
+	rootCmd.AddCommand(cmd)
+}
+
+type rtCmd struct {
+	status statusCmd
+	from   date
+	growth bool
+}
+
+func (c *rtCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	if c.from.Time().IsZero() {
+		c.from = c.status.now.AddDays(-30)
+	}
+	if c.from.Time().After(c.status.now.Time()) {
+		return fmt.Errorf("--from %s is after the last day with data %s", c.from, c.status.now)
+	}
+
+	rts, err := c.reproduction()
+	if err != nil {
+		return err
+	}
+	cases, err := c.status.incidence(c.from)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: reproduction number (Rt), %.0f%% confidence interval\n",
+		strings.ToTitle(c.status.country), c.status.confidence*100)
+	z := calc.Z(c.status.confidence)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	heading := "NEW CASES"
+	if c.status.metric == deathsMetric {
+		heading = "NEW DEATHS"
+	}
+	fmt.Fprintf(w, "DATE\t%s\tRT\tLOW\tHIGH\t\n", heading)
+	for i, rt := range rts {
+		fmt.Fprintf(w, "%s\t%.0f\t", c.from.AddDays(i), cases[i])
+		if math.IsNaN(rt.R) {
+			fmt.Fprintln(w, "-\t-\t-\t")
+			continue
+		}
+		low, high := calc.Interval(rt.R, rt.StdErr, z)
+		fmt.Fprintf(w, "%.2f\t%.2f\t%.2f\t\n", rt.R, low, high)
+	}
+	return w.Flush()
+}
+
+// reproduction numbers of every day, with the selected method.
+func (c *rtCmd) reproduction() ([]calc.Rt, error) {
+	if !c.growth {
+		return c.status.reproduction(c.from)
+	}
+
+	si, err := calc.SerialInterval(c.status.serialInterval, c.status.serialIntervalSD)
+	if err != nil {
+		return nil, err
+	}
+	days := int(c.status.days)
+	incidence, err := c.status.incidence(c.from.AddDays(-days))
+	if err != nil {
+		return nil, err
+	}
+	rts := make([]calc.Rt, len(incidence)-days)
+	for i := range rts {
+		f, err := calc.RateFit(incidence[i:i+days+1], nil)
+		if insufficient(err) || math.IsNaN(f.StdErr) {
+			rts[i] = calc.Rt{R: math.NaN(), StdErr: math.NaN()}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		r, err := calc.GrowthToR(f.Rate, si)
+		if err != nil {
+			return nil, err
+		}
+		// the interval of R, from the interval of the rate (R grows with the rate)
+		low, _ := calc.Interval(f.Rate, f.StdErr, 1)
+		rLow, err := calc.GrowthToR(low, si)
+		if err != nil {
+			return nil, err
+		}
+		rts[i] = calc.Rt{R: r, StdErr: math.Log(r / rLow)}
+	}
+	return rts, nil
+}
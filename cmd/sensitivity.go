@@ -0,0 +1,206 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/view"
+)
+
+// Formats of the outputs.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+func init() {
+	c := &sensitivityCmd{}
+	cmd := &cobra.Command{
+		Use:   "sensitivity [COUNTRY]",
+		Short: "Prints how the status of the selected COUNTRY changes with the periods of the estimates",
+		Long: `Prints how the status of the selected COUNTRY changes with the periods of the estimates,
+to judge whether a score is robust before publishing it.
+
+The status is computed for every pair of periods, with --days from 3 to --maxDays
+and --compareDays up to twice --maxDays, and printed as a table with a row per --days
+and a column per --compareDays, showing the --value of each pair: score (the default), rate or dimFactor.
+The pair of --days and --compareDays is marked with "*", and pairs with insufficient data are shown as "-".
+With --format json, every value of every pair is printed as JSON.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Uint8Var(&c.maxDays, "maxDays", 14, "longest period of the estimate, in days")
+	cmd.Flags().StringVar(&c.value, "value", "score", "value shown in the table: score, rate or dimFactor")
+	cmd.Flags().StringVarP(&c.format, "format", "f", formatText, "output format: text or json")
+	rootCmd.AddCommand(cmd)
+}
+
+type sensitivityCmd struct {
+	status        statusCmd
+	maxDays       uint8
+	value, format string
+}
+
+type (
+	sensitivityGrid struct {
+		Location       string            `json:"location"`
+		Updated        string            `json:"updated"`
+		Method         string            `json:"method"`
+		Deseasonalized bool              `json:"deseasonalized,omitempty"`
+		Days           uint8             `json:"days"`
+		CompareDays    uint8             `json:"compareDays"`
+		Score          uint8             `json:"score,omitempty"`
+		Cells          []sensitivityCell `json:"cells"`
+	}
+	sensitivityCell struct {
+		Days        uint8    `json:"days"`
+		CompareDays uint8    `json:"compareDays"`
+		Score       uint8    `json:"score,omitempty"`
+		Label       string   `json:"label"`
+		Rate        *float64 `json:"rate"`
+		DimFactor   *float64 `json:"dimFactor"`
+	}
+)
+
+func (c *sensitivityCmd) run(_ *cobra.Command, args []string) error {
+	switch {
+	case c.value != "score" && c.value != "rate" && c.value != "dimFactor":
+		return fmt.Errorf("unknown value `%s`", c.value)
+	case c.format != formatText && c.format != formatJSON:
+		return fmt.Errorf("unsupported format `%s`", c.format)
+	case c.maxDays < minTuneDays:
+		return fmt.Errorf("--maxDays should be at least %d", minTuneDays)
+	}
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+
+	grid := sensitivityGrid{
+		Location:       strings.ToTitle(c.status.country),
+		Updated:        c.status.now.String(),
+		Method:         c.status.method.String(),
+		Deseasonalized: c.status.deseasonalize,
+		Days:           c.status.days,
+		CompareDays:    c.status.compareDays,
+	}
+	selected, err := c.cell(c.status.days, c.status.compareDays)
+	if err != nil {
+		return err
+	}
+	grid.Score = selected.Score
+	for days := minTuneDays; days <= int(c.maxDays); days++ {
+		for compare := days + 1; compare <= maxCompareDays(c.maxDays); compare++ {
+			cell, err := c.cell(uint8(days), uint8(compare))
+			if err != nil {
+				return err
+			}
+			grid.Cells = append(grid.Cells, cell)
+		}
+	}
+
+	if c.format == formatJSON {
+		return json.NewEncoder(os.Stdout).Encode(grid)
+	}
+	return c.print(grid)
+}
+
+// cell of the grid, with the status computed on a pair of periods.
+func (c *sensitivityCmd) cell(days, compareDays uint8) (sensitivityCell, error) {
+	s := c.status
+	s.days, s.compareDays = days, compareDays
+	s = s.at(s.now)
+	v := &view.View{}
+	if err := s.spread(v); err != nil {
+		return sensitivityCell{}, err
+	}
+	cell := sensitivityCell{Days: days, CompareDays: compareDays, Label: view.Label(v.Status.Score)}
+	if !v.Status.InsufficientData {
+		cell.Score = v.Status.Score
+		cell.Rate = number(v.Current.Rate)
+		cell.DimFactor = number(v.Comparison.RateOfRates)
+	}
+	return cell, nil
+}
+
+// print the grid as a table, with how often each score occurs.
+func (c *sensitivityCmd) print(grid sensitivityGrid) error {
+	fmt.Printf("%s: %s by --days (rows) and --compareDays (columns), as of %s (%s)\n",
+		grid.Location, c.value, grid.Updated, c.status.methodName())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "\t")
+	for compare := minTuneDays + 1; compare <= maxCompareDays(c.maxDays); compare++ {
+		fmt.Fprintf(w, "%d\t", compare)
+	}
+	fmt.Fprintln(w)
+	counts := map[uint8]int{}
+	for i, cell := range grid.Cells {
+		if i == 0 || cell.Days != grid.Cells[i-1].Days {
+			fmt.Fprintf(w, "%d\t%s", cell.Days, strings.Repeat("\t", int(cell.Days-minTuneDays)))
+		}
+		counts[cell.Score]++
+
+		value := "-"
+		switch {
+		case cell.Score == 0:
+		case c.value == "rate" && cell.Rate != nil:
+			value = fmt.Sprintf("%.2f", *cell.Rate)
+		case c.value == "dimFactor" && cell.DimFactor != nil:
+			value = fmt.Sprintf("%.3f", *cell.DimFactor)
+		case c.value == "score":
+			value = fmt.Sprint(cell.Score)
+		}
+		if cell.Days == grid.Days && cell.CompareDays == grid.CompareDays {
+			value = "*" + value
+		}
+		fmt.Fprintf(w, "%s\t", value)
+		if int(cell.CompareDays) == maxCompareDays(c.maxDays) {
+			fmt.Fprintln(w)
+		}
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nScore with --days %d and --compareDays %d: %d (%s), the same in %.0f%% of the pairs.\n",
+		grid.Days, grid.CompareDays, grid.Score, view.Label(grid.Score),
+		100*float64(counts[grid.Score])/float64(len(grid.Cells)))
+	for score := uint8(0); score <= view.OutOfControl; score++ {
+		if counts[score] > 0 {
+			fmt.Printf("%d (%s): %.0f%% of the pairs\n",
+				score, view.Label(score), 100*float64(counts[score])/float64(len(grid.Cells)))
+		}
+	}
+	return nil
+}
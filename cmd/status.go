@@ -23,132 +23,490 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/calc/analysis"
+	"github.com/jsidew/covid/pkg/database"
 	"github.com/jsidew/covid/pkg/view"
 )
 
 const (
 	dateLayout = "2006-01-02"
 	fcastDays  = 30
+	breakDays  = 5
+	metricDays = 7
+	seasonDays = 56
 )
 
-type date time.Time
+// Metrics of the spread: the active cases, or the daily confirmed cases or deaths averaged over the last week.
+const (
+	activeMetric    metric = "active"
+	confirmedMetric metric = "confirmed"
+	deathsMetric    metric = "deaths"
+)
+
+// Methods to estimate the spread rates.
+const (
+	twoPoint method = "twopoint"
+	ols      method = "ols"
+	wls      method = "wls"
+)
+
+type (
+	date   time.Time
+	method string
+	metric string
+)
 
 func init() {
-	c := &statusCmd{}
+	c := &messageCmd{}
 	cmd := &cobra.Command{
 		Use:   "status [COUNTRY]",
 		Short: "Prints a tweet-long message about COVID-19 situation of the selected COUNTRY",
 		Long: `Prints a tweet-long message about COVID-19 situation of the selected COUNTRY.
 
-COUNTRY is one of the countries with cases as listed with the command 'covid countries';
+COUNTRY is one of the countries with cases as listed with the command 'covid countries',
+or one of its provinces as COUNTRY/PROVINCE (e.g. "China/Hubei");
 to print the status of the whole world, either set COUNTRY to "world" or leave it empty.`,
 		RunE: c.run,
 		Args: cobra.MaximumNArgs(1),
 	}
-	flags := cmd.Flags()
-	flags.Uint8VarP(&c.days, "days", "d", 7, "estimate for the last n days, define either this or --since")
-	flags.Uint8VarP(&c.compareDays, "compareDays", "c", 0, "coparison estimate for the last n days, define either this or --compareSince (default is twice --days)")
-	flags.VarP(&c.since, "since", "s", "when to start the estimate with format: "+dateLayout+", define either this or --days")
-	flags.VarP(&c.compare, "compareSince", "a", "when to start the comparison estimate with format: "+dateLayout+", define either this or --compareDays")
+	c.status.flags(cmd.Flags())
+	cmd.Flags().VarP(&c.status.near, "near", "n", "select the country or province closest to the coordinates with format: LAT,LON, instead of COUNTRY")
+	cmd.Flags().BoolVar(&c.status.compareBreak, "compareBreak", false, "compare with the estimate since the most recent break of the growth rate (see 'covid breaks'), instead of --compareDays")
+	cmd.Flags().BoolVar(&c.tune, "tune", false, "use the periods of the estimates that best projected the active cases (see 'covid tune')")
+	c.tuning.flags(cmd.Flags(), "tune")
 	rootCmd.AddCommand(cmd)
 }
 
+// messageCmd prints the status message, with the periods of the estimates optionally tuned.
+type messageCmd struct {
+	status statusCmd
+	tune   bool
+	tuning tuning
+}
+
 type statusCmd struct {
 	now, since, compare date
 	days, compareDays   uint8
+	estimate            uint8
+	method              method
+	metric              metric
+	confidence          float64
+	serialInterval      float64
+	serialIntervalSD    float64
+	fatalityLag         uint8
+	deseasonalize       bool
 	country             string
+	near                coords
+	compareBreak        bool
 }
 
-func (c *statusCmd) run(_ *cobra.Command, args []string) error {
+// flags of the status computation, shared by the commands computing statuses.
+func (c *statusCmd) flags(flags *pflag.FlagSet) {
+	flags.Uint8VarP(&c.days, "days", "d", 7, "estimate for the last n days, define either this or --since")
+	flags.Uint8VarP(&c.compareDays, "compareDays", "c", 0, "coparison estimate for the last n days, define either this or --compareSince (default is twice --days)")
+	flags.VarP(&c.since, "since", "s", "when to start the estimate with format: "+dateLayout+", define either this or --days")
+	flags.Var(&c.now, "as-of", "anchor the computation at a past date with format: "+dateLayout+", as if it was the latest (default is the latest date with data)")
+	flags.VarP(&c.compare, "compareSince", "a", "when to start the comparison estimate with format: "+dateLayout+", define either this or --compareDays")
+	flags.Uint8VarP(&c.estimate, "estimate", "e", 0, "estimate active cases considering closed the cases older than n days, for missing recovered data (for every country of the run)")
+	c.method = twoPoint
+	flags.VarP(&c.method, "method", "m", "method to estimate the spread rates: "+
+		"twopoint (from first and last day), ols (least squares over every day) or wls (least squares weighted by cases)")
+	c.metric = activeMetric
+	flags.Var(&c.metric, "metric", "metric of the spread: active (active cases), "+
+		"deaths or confirmed (new deaths or confirmed cases of every day, averaged over the last 7 days, when active cases are unreliable)")
+	flags.Float64Var(&c.confidence, "confidence", 0.95, "confidence level of the intervals of rates and projections")
+	flags.Float64Var(&c.serialInterval, "serialInterval", 4.7, "mean days between the symptoms of infector and infected, to estimate the reproduction number")
+	flags.Float64Var(&c.serialIntervalSD, "serialIntervalSD", 2.9, "standard deviation of the days between the symptoms of infector and infected")
+	flags.BoolVar(&c.deseasonalize, "deseasonalize", false, "adjust the active cases for the under-reporting of some days of the week (e.g. weekends), "+
+		"with factors estimated over the last 8 weeks of the country")
+	flags.Uint8Var(&c.fatalityLag, "fatalityLag", 0, "days between the confirmation and the death of cases, for the case fatality ratio")
+}
+
+func (c *messageCmd) run(_ *cobra.Command, args []string) error {
 	v, err := view.New(profile, view.TemplateName(os.Getenv("COVID_TPL")))
 	if err != nil {
 		return err
 	}
 
-	err = c.set(args)
+	if c.tune && c.status.compareBreak {
+		return fmt.Errorf("define either --tune or --compareBreak")
+	}
+
+	err = c.status.set(args)
+	if err != nil {
+		return err
+	}
+
+	if c.tune {
+		list, err := c.tuning.tune(c.status)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("insufficient data to tune the periods of %s", c.status.country)
+		}
+		c.status.days, c.status.compareDays = list[0].days, list[0].compareDays
+		c.status = c.status.at(c.status.now)
+		fmt.Fprintf(os.Stderr, "tuned periods: --days %d --compareDays %d\n", c.status.days, c.status.compareDays)
+	}
+
+	err = c.status.fill(v)
+	if err != nil {
+		return err
+	}
+
+	err = v.Execute(os.Stdout)
+
+	return err
+}
+
+// fill the view with the status of the selected country, for the templates:
+// the commands that don't print the reproduction number or the metrics only need spread.
+func (c *statusCmd) fill(v *view.View) error {
+	err := c.spread(v)
 	if err != nil {
 		return err
 	}
+	err = c.reproductionNumber(v)
+	if err != nil {
+		return err
+	}
+	return c.metrics(v)
+}
 
+// spread of the metric (active cases by default) in the view, with its rates, projections and score.
+func (c *statusCmd) spread(v *view.View) error {
 	pre, start, last, err := c.cases()
 	if err != nil {
 		return err
 	}
 
-	r := calc.Rate(float64(start), float64(last), float64(c.days))
-	f := calc.Forecast(float64(last), r, fcastDays)
-	good := calc.Period(float64(last), 1, r)
+	v.Country = strings.ToTitle(c.country)
+	v.Updated = c.now.Time()
+	v.Current.Since = c.since.Time()
+	v.Confidence = c.confidence
+	v.Metric = c.metric.String()
+	v.Current.Method = c.method.String()
+	v.Current.Cases = int(math.Round(last))
+	v.Current.Estimated = db.Estimated(c.location())
+	v.Forecast.Days = fcastDays
+	v.Events = evs.Of(c.location()).Until(c.now.Time())
+
+	var season *analysis.Seasonality
+	if c.deseasonalize {
+		s, err := c.seasonality()
+		if err != nil && !insufficient(err) {
+			return err
+		} else if err == nil {
+			season = &s
+			v.Current.Deseasonalized = true
+			v.Current.Seasonality = s
+		}
+	}
+
+	err = c.compute(v, pre, start, last, season)
+	if insufficient(err) {
+		v.Status.InsufficientData = true
+		return nil
+	}
+	return err
+}
+
+// reproductionNumber of the last --days in the view: NaN with insufficient data.
+func (c *statusCmd) reproductionNumber(v *view.View) error {
+	rts, err := c.reproduction(c.now)
+	switch {
+	case insufficient(err):
+		v.Current.Rt, v.Current.RtLow, v.Current.RtHigh = math.NaN(), math.NaN(), math.NaN()
+	case err != nil:
+		return err
+	default:
+		rt := rts[len(rts)-1]
+		v.Current.Rt = rt.R
+		v.Current.RtLow, v.Current.RtHigh = calc.Interval(rt.R, rt.StdErr, calc.Z(c.confidence))
+	}
+	return nil
+}
+
+/*
+metrics of the new cases and deaths in the view, with their rolling averages of the last week
+and their change from the week before, and the case fatality ratio:
+the changes and the ratio are NaN without cases to compare with.
+*/
+func (c *statusCmd) metrics(v *view.View) error {
+	from := c.now.AddDays(1 - 2*metricDays)
+	cases, err := c.newCases(from)
+	if err != nil {
+		return err
+	}
+	deaths, err := c.newDeaths(from)
+	if err != nil {
+		return err
+	}
+	v.Metrics.Days = metricDays
+	v.Metrics.NewCases, v.Metrics.NewCasesAverage, v.Metrics.NewCasesChange = weekly(cases)
+	v.Metrics.NewDeaths, v.Metrics.NewDeathsAverage, v.Metrics.NewDeathsChange = weekly(deaths)
+
+	v.Metrics.FatalityLag = int(c.fatalityLag)
+	v.Metrics.CaseFatality, err = c.caseFatality(c.now)
+	return err
+}
+
+// caseFatality ratio on a day: the deaths over the confirmed cases --fatalityLag days before, NaN without cases.
+func (c *statusCmd) caseFatality(t date) (float64, error) {
+	dead, err := db.Cases("dead", c.location(), t.Time())
+	if err != nil {
+		return 0, err
+	}
+	confirmed, err := db.Cases("confirmed", c.location(), t.AddDays(-int(c.fatalityLag)).Time())
+	if err != nil {
+		return 0, err
+	}
+	if confirmed <= 0 {
+		return math.NaN(), nil
+	}
+	return float64(dead) / float64(confirmed), nil
+}
+
+// compute rates, projections and score of the view from the active cases, adjusted by the seasonality if any.
+func (c *statusCmd) compute(v *view.View, pre, start, last float64, season *analysis.Seasonality) error {
+	current, comparison, err := c.rates(pre, start, last, season)
+	if err != nil {
+		return err
+	}
+
+	r := current.Rate
+	f, err := calc.Forecast(last, r, fcastDays)
+	if err != nil {
+		return err
+	}
+	good, err := calc.Period(last, 1, r)
+	if err != nil {
+		good = math.Inf(1)
+	}
 
 	var growth string
-	g := (f/float64(last) - 1) * 100
+	g := (f/last - 1) * 100
 	if g > 0 {
 		growth = "+"
 	}
 	growth = fmt.Sprintf("%s%.0f%%", growth, g)
 
-	v.Country = strings.ToTitle(c.country)
-	v.Updated = c.now.Time()
 	v.Current.Rate = r
-	v.Current.Cases = int(last)
+	v.Current.StdErr = current.StdErr
+	v.Current.R2 = current.R2
+	v.Current.DoublingTime = doublingTime(r, 1)
+	v.Current.Halving = r < 1
 	v.Recovery.DaysTo1 = good
 	v.Forecast.Cases = f
-	v.Forecast.Days = fcastDays
 	v.Forecast.Growth = growth
 	{
-		r2 := calc.Rate(float64(pre), float64(last), float64(c.compareDays))
-		r3 := calc.Rate(r2, r, float64(c.compareDays-c.days))
-		recovery := calc.Period(r, 0.94, r3)
-		peak := calc.Period(r, 1, r3)
-		peakCases := calc.Forecast3D(float64(last), r, r3, peak)
+		q := float64(int(c.compareDays) - int(c.days))
+		r2 := comparison.Rate
+		r3, err := calc.Rate(r2, r, q)
+		if err != nil {
+			return err
+		}
+		recovery := daysToStart(r, r3)
+		peak, peakCases := peak(last, r, r3)
 
 		v.Comparison.Rate = r2
+		v.Comparison.StdErr = comparison.StdErr
+		v.Comparison.R2 = comparison.R2
 		v.Comparison.RateOfRates = r3
 		v.Recovery.DaysToStart = recovery
 		v.Recovery.DaysToPeak = peak
 		v.Recovery.PeakCases = peakCases
+		v.Forecast.DoublingTime = doublingTime(r, r3)
 
-		improving := r3 < 0.998
-		status := view.OutOfControl
-
-		if r < 0.94 {
-			status = view.Resolving
-		} else if r < 0.99 {
-			status = view.ResolvingSlowly
-		} else if r < 1.05 || (r < 1.09 && improving) {
-			status = view.UnderControl
-		} else if (r < 1.09 && !improving) || (r < 1.14 && improving) {
-			status = view.BarelyUnderControl
-		} else if r < 1.14 && !improving {
-			status = view.LoosingControl
-		} else if improving {
-			status = view.HardToControl
-		}
+		status, improving := score(r, r3)
 
 		v.Status.Score = status
 		v.Status.Resolving = status == view.Resolving || status == view.ResolvingSlowly
 		v.Status.Improving = improving && !v.Status.Resolving
 
+		se := calc.RateOfRatesStdErr(current.StdErr, comparison.StdErr, q)
+		c.bounds(v, last, current, calc.Fit{Rate: r3, StdErr: se})
 	}
 
-	err = v.Execute(os.Stdout)
+	return nil
+}
 
-	return err
+/*
+bounds of the rates, projections and score within the confidence level:
+without a finite standard error (e.g. least squares over 2 days) the bounds are NaN and the score is kept.
+*/
+func (c *statusCmd) bounds(v *view.View, last float64, rate, rateOfRates calc.Fit) {
+	v.Status.ScoreLow, v.Status.ScoreHigh, v.Status.Stable = v.Status.Score, v.Status.Score, true
+	if !view.Finite(rate.StdErr) || !view.Finite(rateOfRates.StdErr) {
+		nan := math.NaN()
+		v.Current.RateLow, v.Current.RateHigh = nan, nan
+		v.Comparison.RateOfRatesLow, v.Comparison.RateOfRatesHigh = nan, nan
+		v.Forecast.CasesLow, v.Forecast.CasesHigh = nan, nan
+		v.Recovery.PeakCasesLow, v.Recovery.PeakCasesHigh = nan, nan
+		v.Recovery.DaysToStartLow, v.Recovery.DaysToStartHigh = nan, nan
+		return
+	}
+
+	z := calc.Z(c.confidence)
+	rl, rh := calc.Interval(rate.Rate, rate.StdErr, z)
+	xl, xh := calc.Interval(rateOfRates.Rate, rateOfRates.StdErr, z)
+
+	v.Current.RateLow, v.Current.RateHigh = rl, rh
+	v.Comparison.RateOfRatesLow, v.Comparison.RateOfRatesHigh = xl, xh
+	v.Forecast.CasesLow = last * math.Pow(rl, fcastDays)
+	v.Forecast.CasesHigh = last * math.Pow(rh, fcastDays)
+
+	v.Recovery.PeakCasesLow, v.Recovery.PeakCasesHigh = math.Inf(1), math.Inf(-1)
+	v.Recovery.DaysToStartLow, v.Recovery.DaysToStartHigh = math.Inf(1), math.Inf(-1)
+	for _, r := range []float64{rl, rh} {
+		for _, x := range []float64{xl, xh} {
+			s, _ := score(r, x)
+			if s < v.Status.ScoreLow {
+				v.Status.ScoreLow = s
+			}
+			if s > v.Status.ScoreHigh {
+				v.Status.ScoreHigh = s
+			}
+			_, peak := peak(last, r, x)
+			v.Recovery.PeakCasesLow = math.Min(v.Recovery.PeakCasesLow, peak)
+			v.Recovery.PeakCasesHigh = math.Max(v.Recovery.PeakCasesHigh, peak)
+			start := daysToStart(r, x)
+			v.Recovery.DaysToStartLow = math.Min(v.Recovery.DaysToStartLow, start)
+			v.Recovery.DaysToStartHigh = math.Max(v.Recovery.DaysToStartHigh, start)
+		}
+	}
+	v.Status.Stable = v.Status.ScoreLow == v.Status.ScoreHigh
+}
+
+/*
+rates of spread of the current and comparison periods, estimated according to the method,
+from the active cases adjusted by the seasonality if any (see --deseasonalize).
+*/
+func (c *statusCmd) rates(pre, start, last float64, season *analysis.Seasonality) (current, comparison calc.Fit, err error) {
+	if c.method == twoPoint {
+		p, s, l := pre, start, last
+		if season != nil {
+			p /= season[c.compare.Time().Weekday()]
+			s /= season[c.since.Time().Weekday()]
+			l /= season[c.now.Time().Weekday()]
+		}
+		current.Rate, err = calc.Rate(s, l, float64(c.days))
+		if err != nil {
+			return
+		}
+		current.StdErr = calc.RateStdErr(s, l, float64(c.days))
+		comparison.Rate, err = calc.Rate(p, l, float64(c.compareDays))
+		if err != nil {
+			return
+		}
+		comparison.StdErr = calc.RateStdErr(p, l, float64(c.compareDays))
+		return
+	}
+
+	from := c.compare
+	if c.since.Time().Before(from.Time()) {
+		from = c.since
+	}
+	series, err := c.series(from)
+	if err != nil {
+		return
+	}
+	if season != nil {
+		series = season.Adjust(series, from.Time().Weekday())
+	}
+	tail := func(days uint8) []float64 {
+		return series[len(series)-1-int(days):]
+	}
+	fit := func(s []float64) (calc.Fit, error) {
+		if c.method == wls {
+			return calc.RateFit(s, s)
+		}
+		return calc.RateFit(s, nil)
+	}
+	current, err = fit(tail(c.days))
+	if err != nil {
+		return
+	}
+	comparison, err = fit(tail(c.compareDays))
+	return
 }
 
 func (c *statusCmd) set(args []string) error {
-	// setting dates
-	if t, err := db.Latest(); err != nil {
+	err := c.setDates()
+	if err != nil {
+		return err
+	}
+
+	// setting country
+	switch {
+	case len(args) > 0 && c.near.set:
+		return fmt.Errorf("define either COUNTRY or --near")
+	case len(args) > 0:
+		c.setCountry(args[0])
+	case c.near.set:
+		p, err := db.Closest(c.near.lat, c.near.long)
+		if err != nil {
+			return err
+		}
+		c.setCountry(p.Location())
+	default:
+		c.setCountry("world")
+	}
+
+	if c.compareBreak {
+		return c.setCompareBreak()
+	}
+	return nil
+}
+
+/*
+setCompareBreak sets the comparison date to the most recent break of the growth rate of the cases of the metric
+before the start of the estimate: if there is none within 255 days, the comparison date is kept.
+*/
+func (c *statusCmd) setCompareBreak() error {
+	from, segments, err := c.breaks(breakDays)
+	if insufficient(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for i := len(segments) - 1; i > 0; i-- {
+		t := from.AddDays(segments[i].From)
+		days := c.now.DaysFrom(t)
+		if days > math.MaxUint8 {
+			return nil
+		}
+		if t.Time().Before(c.since.Time()) && days > int(c.days) {
+			c.compare, c.compareDays = t, uint8(days)
+			fmt.Fprintf(os.Stderr, "comparison since the break of %s: --compareDays %d\n", t, c.compareDays)
+			return nil
+		}
+	}
+	return nil
+}
+
+// setDates of the computation, anchored to the latest data unless now is set (--as-of).
+func (c *statusCmd) setDates() error {
+	latest, err := db.Latest()
+	if err != nil {
 		return err
-	} else {
-		c.now = date(t)
+	}
+	if c.now.Time().IsZero() {
+		c.now = date(latest)
+	} else if c.now.Time().After(latest) {
+		return fmt.Errorf("--as-of %s is after the latest data of %s", c.now, date(latest))
 	}
 	if c.since.Time().IsZero() && c.days > 0 {
 		c.since = c.now.AddDays(-int(c.days))
@@ -165,36 +523,357 @@ func (c *statusCmd) set(args []string) error {
 	if !c.compare.Time().IsZero() && c.compareDays == 0 {
 		c.compareDays = c.now.DaysSince(c.compare)
 	}
-
-	// setting country
-	if len(args) > 0 {
-		c.country = strings.TrimSpace(args[0])
-	} else {
-		c.country = "world"
+	if c.compareDays <= c.days {
+		return fmt.Errorf("the comparison period (%d days) should be longer than the estimate period (%d days)", c.compareDays, c.days)
+	}
+	if c.deseasonalize && c.metric != activeMetric {
+		return fmt.Errorf("--deseasonalize only adjusts the active cases: the %s are already averaged over a week", c.metric.noun())
 	}
-
 	return nil
 }
 
-func (c *statusCmd) cases() (pre, start, last int, err error) {
-	country := c.country
-	if strings.EqualFold(country, "world") {
-		country = ""
+// at a past date, a copy of the computation with the same periods (--days and --compareDays).
+func (c statusCmd) at(t date) statusCmd {
+	c.now = t
+	c.since = t.AddDays(-int(c.days))
+	c.compare = t.AddDays(-int(c.compareDays))
+	return c
+}
+
+// setCountry selects a country, with the resolution period of the run (--estimate) if any.
+func (c *statusCmd) setCountry(country string) {
+	c.country = strings.TrimSpace(country)
+	if c.estimate > 0 {
+		db.Estimate(c.location(), int(c.estimate))
 	}
-	last, err = db.ActiveCases(country, c.now.Time())
+}
+
+// cases of the metric at the comparison date, at the start date and now.
+func (c *statusCmd) cases() (pre, start, last float64, err error) {
+	last, err = c.value(c.now)
 	if err != nil {
 		return
 	}
-	start, err = db.ActiveCases(country, c.since.Time())
+	start, err = c.value(c.since)
 	if err != nil {
 		return
 	}
 	if !c.compare.Time().IsZero() {
-		pre, err = db.ActiveCases(country, c.compare.Time())
+		pre, err = c.value(c.compare)
+	}
+	return
+}
+
+// value of the metric on a day.
+func (c *statusCmd) value(t date) (float64, error) {
+	if c.metric == activeMetric {
+		n, err := db.ActiveCases(c.location(), t.Time())
+		return float64(n), err
+	}
+	s := c.at(t)
+	series, err := s.series(t)
+	if err != nil {
+		return 0, err
+	}
+	return series[0], nil
+}
+
+// series of the metric, one per day from a date to now.
+func (c *statusCmd) series(from date) ([]float64, error) {
+	switch c.metric {
+	case confirmedMetric:
+		return c.averaged("confirmed", from)
+	case deathsMetric:
+		return c.averaged("dead", from)
+	}
+	return c.activeSeries(from)
+}
+
+// averaged daily increments of a series of cumulative cases (see daily), over the last week of every day from a date to now.
+func (c *statusCmd) averaged(n database.EndpointName, from date) ([]float64, error) {
+	series, err := c.daily(n, from.AddDays(1-metricDays))
+	if err != nil {
+		return nil, err
+	}
+	return calc.RollingMean(series, metricDays)
+}
+
+// activeSeries of active cases, one per day from a date to now.
+func (c *statusCmd) activeSeries(from date) ([]float64, error) {
+	cases, err := db.ActiveSeries(c.location(), from.Time(), c.now.Time())
+	if err != nil {
+		return nil, err
+	}
+	series := make([]float64, len(cases))
+	for i, n := range cases {
+		series[i] = float64(n)
+	}
+	return series, nil
+}
+
+// breaks of the growth rate of the cases of the metric (see analysis.Breaks), since the last day without cases.
+func (c *statusCmd) breaks(minDays int) (from date, segments []analysis.Segment, err error) {
+	first, err := db.First()
+	if err != nil {
+		return
+	}
+	series, err := c.series(date(first))
+	if err != nil {
+		return
+	}
+	start := 0
+	for i, n := range series {
+		if n <= 0 {
+			start = i + 1
+		}
 	}
+	from = date(first).AddDays(start)
+	segments, err = analysis.Breaks(series[start:], minDays)
 	return
 }
 
+// seasonality of the active cases by day of the week (see analysis.Weekly), over the last weeks.
+func (c *statusCmd) seasonality() (analysis.Seasonality, error) {
+	series, err := c.series(c.now.AddDays(1 - seasonDays))
+	if err != nil {
+		return analysis.Seasonality{}, err
+	}
+	return analysis.Weekly(series, c.now.AddDays(1-seasonDays).Time().Weekday())
+}
+
+// waves of the cases of the metric (see analysis.Waves), since the first day with cases.
+func (c *statusCmd) waves(prominence float64) (from date, waves []analysis.Wave, err error) {
+	first, err := db.First()
+	if err != nil {
+		return
+	}
+	series, err := c.series(date(first))
+	if err != nil {
+		return
+	}
+	start := 0
+	for start < len(series)-1 && series[start] <= 0 {
+		start++
+	}
+	from = date(first).AddDays(start)
+	waves, err = analysis.Waves(series[start:], prominence)
+	return
+}
+
+// incidence of every day from a date to now, for the reproduction number:
+// the new deaths with --metric deaths, the new confirmed cases otherwise.
+func (c *statusCmd) incidence(from date) ([]float64, error) {
+	if c.metric == deathsMetric {
+		return c.newDeaths(from)
+	}
+	return c.newCases(from)
+}
+
+// newCases of every day from a date to now, from the confirmed cases (see daily).
+func (c *statusCmd) newCases(from date) ([]float64, error) {
+	return c.daily("confirmed", from)
+}
+
+// newDeaths of every day from a date to now, from the dead cases (see daily).
+func (c *statusCmd) newDeaths(from date) ([]float64, error) {
+	return c.daily("dead", from)
+}
+
+// daily increments of a series of cumulative cases, every day from a date to now:
+// negative numbers, from corrections of the data, are set to zero.
+func (c *statusCmd) daily(n database.EndpointName, from date) ([]float64, error) {
+	cases, err := db.Series(n, c.location(), from.AddDays(-1).Time(), c.now.Time())
+	if err != nil {
+		return nil, err
+	}
+	series := make([]float64, len(cases)-1)
+	for i := range series {
+		if n := cases[i+1] - cases[i]; n > 0 {
+			series[i] = float64(n)
+		}
+	}
+	return series, nil
+}
+
+// reproduction numbers of every day from a date to now,
+// estimated from the new cases of the last --days of each day.
+func (c *statusCmd) reproduction(from date) ([]calc.Rt, error) {
+	si, err := calc.SerialInterval(c.serialInterval, c.serialIntervalSD)
+	if err != nil {
+		return nil, err
+	}
+	// the new cases before the first window are the infectors of the first day
+	incidence, err := c.incidence(from.AddDays(-int(c.days) - len(si)))
+	if err != nil {
+		return nil, err
+	}
+	rts, err := calc.Reproduction(incidence, si, int(c.days))
+	if err != nil {
+		return nil, err
+	}
+	return rts[len(si):], nil
+}
+
+// score of the Virus Control Scale, from the spread rate and the rate of rates,
+// with whether the situation is improving.
+func score(r, r3 float64) (uint8, bool) {
+	improving := r3 < 0.998
+	status := view.OutOfControl
+
+	if r < 0.94 {
+		status = view.Resolving
+	} else if r < 0.99 {
+		status = view.ResolvingSlowly
+	} else if r < 1.05 || (r < 1.09 && improving) {
+		status = view.UnderControl
+	} else if (r < 1.09 && !improving) || (r < 1.14 && improving) {
+		status = view.BarelyUnderControl
+	} else if r < 1.14 && !improving {
+		status = view.LoosingControl
+	} else if improving {
+		status = view.HardToControl
+	}
+
+	return status, improving
+}
+
+// peak of active cases, with the days to reach it, at spread rate r varying at rate x:
+// infinite if the spread never stops.
+func peak(last, r, x float64) (days, cases float64) {
+	if r <= 1 {
+		return 0, last
+	}
+	days, err := calc.Period(r, 1, x)
+	if err != nil {
+		return math.Inf(1), math.Inf(1)
+	}
+	cases, err = calc.Forecast3D(last, r, x, days)
+	if err != nil {
+		return math.Inf(1), math.Inf(1)
+	}
+	return days, cases
+}
+
+// daysToStart resolving, at spread rate r varying at rate x: infinite if the spread never stops.
+func daysToStart(r, x float64) float64 {
+	if r < 0.94 {
+		return 0
+	}
+	days, err := calc.Period(r, 0.94, x)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return days
+}
+
+/*
+weekly metrics of a series of daily numbers, the last day included:
+the number of the last day, its rolling average of the last week,
+and the change of the average from the week before, as a fraction (NaN if the average was 0).
+*/
+func weekly(series []float64) (last int, average, change float64) {
+	last = int(series[len(series)-1])
+	means, err := calc.RollingMean(series, metricDays)
+	if err != nil {
+		return last, math.NaN(), math.NaN()
+	}
+	average = means[len(means)-1]
+	change = math.NaN()
+	if len(means) > metricDays && means[len(means)-1-metricDays] > 0 {
+		change = average/means[len(means)-1-metricDays] - 1
+	}
+	return
+}
+
+// doublingTime, or halving time, at spread rate r varying at rate x: infinite if it never doubles (or halves).
+func doublingTime(r, x float64) float64 {
+	days, err := calc.DoublingTime(r, x)
+	if err != nil {
+		return math.Inf(1)
+	}
+	return days
+}
+
+// insufficient data to compute a status, for the error of a calc function.
+func insufficient(err error) bool {
+	for _, e := range []error{calc.ErrInsufficientData, calc.ErrNegative, calc.ErrUnitRate, calc.ErrUnreachable} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// methodName of the estimates of the rates, for the titles of the outputs (e.g. "ols method on deaths, deseasonalized").
+func (c *statusCmd) methodName() string {
+	name := c.method.String() + " method"
+	if c.metric != activeMetric {
+		name += " on " + c.metric.String()
+	}
+	if c.deseasonalize {
+		name += ", deseasonalized"
+	}
+	return name
+}
+
+// location of the selected country, as queried in the database.
+func (c *statusCmd) location() string {
+	if strings.EqualFold(c.country, "world") {
+		return ""
+	}
+	return c.country
+}
+
+func (m method) String() string {
+	return string(m)
+}
+
+func (m method) Type() string {
+	return "method"
+}
+
+func (m *method) Set(s string) error {
+	switch n := method(strings.ToLower(strings.TrimSpace(s))); n {
+	case twoPoint, ols, wls:
+		*m = n
+		return nil
+	}
+	return fmt.Errorf("unknown method `%s`", s)
+}
+
+func (m metric) String() string {
+	return string(m)
+}
+
+func (m metric) Type() string {
+	return "metric"
+}
+
+func (m *metric) Set(s string) error {
+	switch n := metric(strings.ToLower(strings.TrimSpace(s))); n {
+	case activeMetric, confirmedMetric, deathsMetric:
+		*m = n
+		return nil
+	}
+	return fmt.Errorf("unknown metric `%s`", s)
+}
+
+// noun of the metric in the outputs (e.g. "active cases").
+func (m metric) noun() string {
+	switch m {
+	case confirmedMetric:
+		return "daily confirmed cases"
+	case deathsMetric:
+		return "daily deaths"
+	}
+	return "active cases"
+}
+
+// heading of the metric in the tables (e.g. "ACTIVE").
+func (m metric) heading() string {
+	return strings.ToUpper(m.String())
+}
+
 func (d date) Time() time.Time {
 	return time.Time(d)
 }
@@ -203,6 +882,11 @@ func (d date) DaysSince(s date) uint8 {
 	return uint8(d.Time().Sub(s.Time()).Minutes() / 60 / 24)
 }
 
+// DaysFrom is like DaysSince, without wrapping around past 255 days: negative if d is before s.
+func (d date) DaysFrom(s date) int {
+	return int(math.Round(d.Time().Sub(s.Time()).Hours() / 24))
+}
+
 func (d date) AddDays(days int) date {
 	return date(d.Time().Add(time.Duration(days) * 24 * time.Hour))
 }
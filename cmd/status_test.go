@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/view"
+)
+
+func TestBoundsWithoutStdErr(t *testing.T) {
+	// least squares over 2 days, with no residuals to estimate the standard error
+	fit, err := calc.RateFit([]float64{100, 120}, nil)
+	require.NoError(t, err)
+	require.True(t, math.IsNaN(fit.StdErr), "standard error of 2 points")
+
+	c := &statusCmd{confidence: 0.95}
+	v := &view.View{}
+	v.Status.Score = view.UnderControl
+	c.bounds(v, 1000, fit, calc.Fit{Rate: 0.99, StdErr: 0.01})
+	assert.Equal(t, view.UnderControl, v.Status.ScoreLow, "low score")
+	assert.Equal(t, view.UnderControl, v.Status.ScoreHigh, "high score")
+	assert.True(t, v.Status.Stable, "stable")
+	assert.True(t, math.IsNaN(v.Current.RateLow), "rate interval")
+	assert.False(t, view.Finite(v.Recovery.PeakCasesHigh), "peak interval")
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jsidew/covid/pkg/synth"
+)
+
+func init() {
+	c := &synthCmd{}
+	c.start.Set("2020-01-22")
+	cmd := &cobra.Command{
+		Use:   "synth",
+		Short: "Generates a synthetic dataset of COVID-19 cases",
+		Long: `Generates a synthetic dataset of COVID-19 cases, as CSV files of confirmed, recovered and dead cases
+in the same format of the data source, from known spread rates and dim factors.
+
+Each country is defined with --country NAME,RATE,DIMFACTOR[,CASES[,LAT,LON]] (e.g. --country Atlantis,1.15,0.995,100),
+where CASES are the active cases on the first day; NAME can also be COUNTRY/PROVINCE.
+Use the generated files with the flag --data of the other commands (e.g. covid --data DIR status atlantis).`,
+		RunE: c.run,
+		Args: cobra.NoArgs,
+	}
+	flags := cmd.Flags()
+	flags.StringArrayVar(&c.countries, "country", []string{"Synthland,1.15,0.995,100"}, "country parameters with format: NAME,RATE,DIMFACTOR[,CASES[,LAT,LON]]")
+	flags.VarP(&c.start, "start", "s", "first day with format: "+dateLayout)
+	flags.IntVarP(&c.cfg.Days, "days", "d", 60, "number of days")
+	flags.Float64Var(&c.closing, "closing", 1.0/14, "fraction of active cases closed daily (recovered or dead)")
+	flags.Float64Var(&c.fatality, "fatality", 0.05, "fraction of closed cases that are dead")
+	flags.Float64Var(&c.cfg.Noise, "noise", 0, "standard deviation of the log-normal noise on daily cases")
+	flags.Float64Var(&c.cfg.Weekend, "weekend", 0, "fraction of the daily cases of weekends reported on Mondays")
+	flags.IntVar(&c.cfg.RecoveredDays, "recoveredDays", 0, "stop reporting recovered cases after n days (0 for never)")
+	flags.Int64Var(&c.cfg.Seed, "seed", 1, "seed of the random noise")
+	flags.StringVarP(&c.out, "out", "o", ".", "output directory")
+	rootCmd.AddCommand(cmd)
+}
+
+type synthCmd struct {
+	cfg               synth.Config
+	start             date
+	countries         []string
+	closing, fatality float64
+	out               string
+}
+
+func (c *synthCmd) run(*cobra.Command, []string) error {
+	c.cfg.Start = c.start.Time()
+	for _, spec := range c.countries {
+		country, err := c.country(spec)
+		if err != nil {
+			return err
+		}
+		c.cfg.Countries = append(c.cfg.Countries, country)
+	}
+
+	d, err := synth.Generate(c.cfg)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(c.out, os.ModeDir|0755)
+	if err != nil {
+		return err
+	}
+	for name, cases := range map[string][][]int{
+		"confirmed": d.Confirmed,
+		"recovered": d.Recovered,
+		"dead":      d.Deaths,
+	} {
+		f, err := os.Create(filepath.Join(c.out, name+".csv"))
+		if err != nil {
+			return err
+		}
+		err = d.WriteCSV(f, cases)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Println(f.Name())
+	}
+
+	return nil
+}
+
+func (c *synthCmd) country(spec string) (synth.Country, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) != 3 && len(parts) != 4 && len(parts) != 6 {
+		return synth.Country{}, fmt.Errorf("country `%s` should have format NAME,RATE,DIMFACTOR[,CASES[,LAT,LON]]", spec)
+	}
+	country := synth.Country{Cases: 1, Closing: c.closing, Fatality: c.fatality}
+	country.Name = strings.TrimSpace(parts[0])
+	if i := strings.Index(country.Name, "/"); i >= 0 {
+		country.Name, country.Province = country.Name[:i], country.Name[i+1:]
+	}
+	values := []*float64{&country.Rate, &country.DimFactor, &country.Cases, &country.Lat, &country.Long}
+	for i, p := range parts[1:] {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return synth.Country{}, fmt.Errorf("invalid number `%s` for country `%s`", p, country.Name)
+		}
+		*values[i] = f
+	}
+	return country, nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Shortest period of the estimate, in days, when tuning.
+const minTuneDays = 3
+
+func init() {
+	c := &tuneCmd{}
+	cmd := &cobra.Command{
+		Use:   "tune [COUNTRY]",
+		Short: "Finds the periods of the estimates that best projected the active cases in the selected COUNTRY",
+		Long: `Finds the periods of the estimates (--days and --compareDays) that best projected the active cases in the selected COUNTRY.
+
+Every pair of periods, up to --maxDays for --days and twice that for --compareDays, is backtested (see 'covid backtest')
+over the last --weeks, and the pairs with a forecast for every past day are sorted by the mean absolute percentage error (MAPE)
+of the projections of a model after --horizon days.
+The tuned periods can be used in 'covid status' with --tune.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	c.tuning.flags(cmd.Flags(), "")
+	cmd.Flags().IntVar(&c.top, "top", 10, "number of best pairs of periods to print")
+	rootCmd.AddCommand(cmd)
+}
+
+type tuneCmd struct {
+	status statusCmd
+	tuning tuning
+	top    int
+}
+
+type (
+	// tuning of the periods of the estimates.
+	tuning struct {
+		weeks, horizon int
+		maxDays        uint8
+		model          string
+	}
+
+	// tuned periods, with the accuracy of their projections.
+	tuned struct {
+		days, compareDays uint8
+		accuracy
+	}
+)
+
+func (c *tuneCmd) run(_ *cobra.Command, args []string) error {
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	list, err := c.tuning.tune(c.status)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("insufficient data to tune the periods of %s", c.status.country)
+	}
+
+	fmt.Printf("%s: periods with the best %s projections after %d days, over the last %d weeks (%s)\n",
+		strings.ToTitle(c.status.country), c.tuning.model, c.tuning.horizon, c.tuning.weeks, c.status.methodName())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAYS\tCOMPARE DAYS\tFORECASTS\tMAE\tMAPE\t")
+	for i, t := range list {
+		if i == c.top {
+			break
+		}
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.0f\t%.1f%%\t\n", t.days, t.compareDays, t.n, t.MAE(), t.MAPE()*100)
+	}
+	return w.Flush()
+}
+
+// flags of the tuning, shared by the commands tuning the periods, with names prefixed where they'd clash with other flags.
+func (t *tuning) flags(flags *pflag.FlagSet, prefix string) {
+	name := func(s string) string {
+		if prefix == "" {
+			return s
+		}
+		return prefix + strings.ToUpper(s[:1]) + s[1:]
+	}
+	flags.IntVar(&t.weeks, name("weeks"), 4, "weeks of past days to backtest")
+	flags.IntVar(&t.horizon, name("horizon"), 7, "days after each past day to compare the projections with the actual cases")
+	flags.Uint8Var(&t.maxDays, name("maxDays"), 14, "longest period of the estimate to try, in days")
+	flags.StringVar(&t.model, name("model"), vcsProjection, "projection to tune: "+strings.Join(projections, " or "))
+}
+
+/*
+tune the periods of the estimates of a status computation,
+sorted by the accuracy of their projections from the best:
+the pairs of periods with fewer forecasts than others (for insufficient data on some days) are left out.
+*/
+func (t tuning) tune(s statusCmd) ([]tuned, error) {
+	known := false
+	for _, p := range projections {
+		known = known || p == t.model
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown model `%s`", t.model)
+	}
+	if t.weeks < 1 || t.horizon < 1 || t.maxDays < minTuneDays {
+		return nil, fmt.Errorf("the weeks and the horizon should be positive, the longest period at least %d days", minTuneDays)
+	}
+
+	// the periods are counted as int, not to wrap around past the longest comparison period of 255 days
+	from, to := s.now.AddDays(-7*t.weeks-t.horizon), s.now.AddDays(-t.horizon)
+	list := []tuned{}
+	for days := minTuneDays; days <= int(t.maxDays); days++ {
+		for compare := days + 1; compare <= maxCompareDays(t.maxDays); compare++ {
+			s.days, s.compareDays = uint8(days), uint8(compare)
+			acc, err := backtest(s, from, to, []int{t.horizon})
+			if err != nil {
+				return nil, err
+			}
+			if a := acc[projection{t.model, t.horizon}]; a.np > 0 {
+				list = append(list, tuned{s.days, s.compareDays, *a})
+			}
+		}
+	}
+	// the same past days for every pair, dropping the pairs with insufficient data on some days
+	most := 0
+	for _, t := range list {
+		if t.n > most {
+			most = t.n
+		}
+	}
+	full := list[:0]
+	for _, t := range list {
+		if t.n == most {
+			full = append(full, t)
+		}
+	}
+	list = full
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].MAPE() < list[j].MAPE() ||
+			(list[i].MAPE() == list[j].MAPE() && list[i].MAE() < list[j].MAE())
+	})
+	return list, nil
+}
+
+// maxCompareDays is the longest comparison period to try with a longest period of maxDays: twice that, up to 255 days.
+func maxCompareDays(maxDays uint8) int {
+	if 2*int(maxDays) > math.MaxUint8 {
+		return math.MaxUint8
+	}
+	return 2 * int(maxDays)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Jacopo Salvestrini <jsidew@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/text/message"
+)
+
+func init() {
+	c := &wavesCmd{}
+	cmd := &cobra.Command{
+		Use:   "waves [COUNTRY]",
+		Short: "Prints the waves of cases in the selected COUNTRY",
+		Long: `Prints the waves of cases in the selected COUNTRY, with their start, peak and end:
+the projections of 'covid status' only make sense within the current wave.
+
+The waves are found as alternate peaks and troughs of the cases of --metric (active cases by default) since the first day with cases,
+where every peak is higher than the troughs around it by more than --prominence, as a fraction of the highest peak.
+The last wave is ongoing if it ends on the latest day.
+COUNTRY is like in 'covid status'.`,
+		RunE: c.run,
+		Args: cobra.MaximumNArgs(1),
+	}
+	c.status.flags(cmd.Flags())
+	cmd.Flags().Float64Var(&c.prominence, "prominence", 0.1, "least rise of a peak over the troughs around it, as a fraction of the highest peak")
+	rootCmd.AddCommand(cmd)
+}
+
+type wavesCmd struct {
+	status     statusCmd
+	prominence float64
+}
+
+func (c *wavesCmd) run(_ *cobra.Command, args []string) error {
+	if c.prominence <= 0 || c.prominence >= 1 {
+		return fmt.Errorf("--prominence should be between 0 and 1")
+	}
+	err := c.status.set(args)
+	if err != nil {
+		return err
+	}
+	from, waves, err := c.status.waves(c.prominence)
+	if insufficient(err) {
+		return fmt.Errorf("insufficient data to find the waves of %s", c.status.country)
+	} else if err != nil {
+		return err
+	}
+	series, err := c.status.series(from)
+	if err != nil {
+		return err
+	}
+
+	p := message.NewPrinter(message.MatchLanguage("en"))
+	fmt.Printf("%s: waves of %s, with a prominence of %.0f%% of the highest peak\n",
+		strings.ToTitle(c.status.country), c.status.metric.noun(), c.prominence*100)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WAVE\tSTART\tPEAK\tPEAK CASES\tEND\tDAYS\t")
+	for i, wave := range waves {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t\n", i+1, from.AddDays(wave.Start), from.AddDays(wave.Peak),
+			p.Sprintf("%.0f", series[wave.Peak]), from.AddDays(wave.End), wave.End-wave.Start+1)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if last := waves[len(waves)-1]; last.End == len(series)-1 {
+		fmt.Printf("\nWave %d is ongoing", len(waves))
+		if last.Peak == last.End {
+			fmt.Print(", and still growing")
+		}
+		fmt.Println(".")
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ go 1.13
 require (
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/spf13/cobra v0.0.6
+	github.com/spf13/pflag v1.0.3
 	github.com/stretchr/testify v1.5.1
 	golang.org/x/text v0.3.2
 	gopkg.in/yaml.v2 v2.2.4 // indirect
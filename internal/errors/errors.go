@@ -24,3 +24,16 @@ func F(format string, a ...interface{}) error {
 func W(err error) error {
 	return &errwrap{Prefix, err}
 }
+
+// Prefixed creates errors with its own prefix, rather than the shared Prefix.
+type Prefixed string
+
+// F returns a formatted error with the prefix p
+func (p Prefixed) F(format string, a ...interface{}) error {
+	return fmt.Errorf(string(p)+": "+format, a...)
+}
+
+// W wraps an error with the prefix p
+func (p Prefixed) W(err error) error {
+	return &errwrap{string(p), err}
+}
@@ -27,3 +27,11 @@ func TestF(t *testing.T) {
 	err := e.F("hello %s", "kitty")
 	assert.EqualError(t, err, prefix+": "+expected)
 }
+
+func TestPrefixed(t *testing.T) {
+	p := e.Prefixed("other")
+	err := p.W(errors.New("hello world"))
+	assert.EqualError(t, err, "other: hello world", "error")
+	assert.EqualError(t, errors.Unwrap(err), "hello world", "unwrapped")
+	assert.EqualError(t, p.F("hello %s", "kitty"), "other: hello kitty", "formatted")
+}
@@ -0,0 +1,52 @@
+/*
+Package location provides the tools shared by the files about locations, like the registry and the events:
+CSV files with a header and a location (COUNTRY or COUNTRY/PROVINCE) in the first column.
+*/
+package location
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Key of a location, to compare locations regardless of case and spaces: "world" is the empty location.
+func Key(location string) string {
+	parts := strings.Split(location, "/")
+	for i := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(parts[i]))
+	}
+	if k := strings.Join(parts, "/"); k != "world" {
+		return k
+	}
+	return ""
+}
+
+// Open a file: if it doesn't exist, it's read as empty.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+	return f, err
+}
+
+// Rows of CSV data after the header, case insensitive: empty data has no rows.
+func Rows(r io.Reader, header ...string) ([][]string, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	h := rows[0]
+	ok := len(h) >= len(header)
+	for i := 0; ok && i < len(header); i++ {
+		ok = strings.EqualFold(strings.TrimSpace(h[i]), header[i])
+	}
+	if !ok {
+		return nil, errors.New("header should be " + strings.Join(header, ","))
+	}
+	return rows[1:], nil
+}
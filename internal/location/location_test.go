@@ -0,0 +1,35 @@
+package location_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/internal/location"
+)
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "china/hubei", location.Key(" China / HUBEI "))
+	assert.Equal(t, "", location.Key(" World "), "world")
+	assert.Equal(t, "", location.Key(""), "empty")
+}
+
+func TestRows(t *testing.T) {
+	rows, err := location.Rows(strings.NewReader("Country,Label\nItaly,lockdown\n"), "country", "label")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Italy", "lockdown"}}, rows)
+
+	_, err = location.Rows(strings.NewReader("country\nItaly\n"), "country", "label")
+	assert.EqualError(t, err, "header should be country,label")
+
+	f, err := location.Open(filepath.Join(t.Name(), "phantom.csv"))
+	require.NoError(t, err, "missing file")
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	assert.Empty(t, b, "missing file")
+}
@@ -0,0 +1,259 @@
+// Package analysis provides analyses of series of cases, one per day, built on the rates of package calc.
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"github.com/jsidew/covid/pkg/calc"
+)
+
+// Segment of a series with its own growth rate, from index From to index To included.
+type Segment struct {
+	From, To int
+
+	// Rate of growth per day of the segment, fitted by least squares (see calc.RateFit).
+	Rate float64
+}
+
+// Wave of a series, from a trough to the next through a peak, as indexes of the series.
+type Wave struct {
+	Start, Peak, End int
+}
+
+/*
+Breaks of the growth rate of a series of positive numbers (e.g. active cases), one per day,
+found by optimal partitioning of the log-linear fit of the series in segments of at least minDays:
+each segment costs its squared errors plus a penalty of 3 times the variance of the noise
+(estimated from the second differences of the logarithms) times the logarithm of the length of the series,
+like the Bayesian information criterion.
+The result are the segments of the series, from the first:
+the breaks are where each segment after the first starts.
+*/
+func Breaks(series []float64, minDays int) ([]Segment, error) {
+	if minDays < 2 {
+		minDays = 2
+	}
+	for _, v := range series {
+		if v <= 0 {
+			return nil, calc.ErrInsufficientData
+		}
+	}
+	n := len(series)
+	if n < minDays {
+		return nil, calc.ErrInsufficientData
+	}
+
+	logs := make([]float64, n)
+	for i, v := range series {
+		logs[i] = math.Log(v)
+	}
+	lines := newLines(logs)
+	penalty := 3 * noise(logs) * math.Log(float64(n))
+
+	// cost[j] is the least cost of the series up to index j excluded, with its last segment starting at start[j]
+	cost := make([]float64, n+1)
+	start := make([]int, n+1)
+	for j := 1; j <= n; j++ {
+		cost[j] = math.Inf(1)
+		for i := 0; i+minDays <= j; i++ {
+			if c := cost[i] + lines.sse(i, j-1) + penalty; c < cost[j] {
+				cost[j], start[j] = c, i
+			}
+		}
+	}
+	if math.IsInf(cost[n], 1) {
+		return nil, calc.ErrInsufficientData
+	}
+
+	segments := []Segment{}
+	for j := n; j > 0; j = start[j] {
+		f, err := calc.RateFit(series[start[j]:j], nil)
+		if err != nil {
+			return nil, err
+		}
+		segments = append([]Segment{{From: start[j], To: j - 1, Rate: f.Rate}}, segments...)
+	}
+	return segments, nil
+}
+
+/*
+Waves of a series of numbers (e.g. active cases), one per day, as alternate peaks and troughs:
+every peak is higher than the troughs around it by more than a prominence,
+as a fraction of the highest number (e.g. 0.1 for 10%).
+The result are the waves from the first, each from a trough to the next through its peak:
+the first wave starts at the first index if there isn't a trough before its peak,
+and the peak of the last wave is the highest number since its start if it's still growing.
+*/
+func Waves(series []float64, prominence float64) ([]Wave, error) {
+	if len(series) < 3 {
+		return nil, calc.ErrInsufficientData
+	}
+	var max float64
+	for _, v := range series {
+		if v < 0 {
+			return nil, calc.ErrNegative
+		}
+		max = math.Max(max, v)
+	}
+	if max == 0 {
+		return nil, calc.ErrInsufficientData
+	}
+	threshold := prominence * max
+
+	// peaks and troughs alternate: rising is the direction of the series since the last of them found
+	var peaks, troughs []int
+	hi, lo, rising, known := 0, 0, false, false
+	for i, v := range series {
+		switch {
+		case !known:
+			if v > series[hi] {
+				hi = i
+			}
+			if v < series[lo] {
+				lo = i
+			}
+			if series[hi]-series[lo] > threshold {
+				known, rising = true, lo < hi
+				if rising {
+					troughs = append(troughs, lo)
+				} else {
+					peaks = append(peaks, hi)
+				}
+			}
+		case rising && v > series[hi]:
+			hi = i
+		case rising && series[hi]-v > threshold:
+			peaks = append(peaks, hi)
+			lo, rising = i, false
+		case !rising && v < series[lo]:
+			lo = i
+		case !rising && v-series[lo] > threshold:
+			troughs = append(troughs, lo)
+			hi, rising = i, true
+		}
+	}
+	if !known {
+		return nil, calc.ErrInsufficientData
+	}
+	if rising {
+		peaks = append(peaks, hi)
+	} else {
+		troughs = append(troughs, lo)
+	}
+
+	// the troughs around every peak, if any before the first
+	if troughs[0] > peaks[0] {
+		troughs = append([]int{0}, troughs...)
+	}
+	if len(troughs) == len(peaks) {
+		troughs = append(troughs, len(series)-1)
+	}
+	waves := make([]Wave, len(peaks))
+	for i, p := range peaks {
+		waves[i] = Wave{Start: troughs[i], Peak: p, End: troughs[i+1]}
+	}
+	return waves, nil
+}
+
+// Seasonality of a series of daily numbers, as multiplicative factors by day of the week (e.g. s[time.Sunday]).
+type Seasonality [7]float64
+
+/*
+Weekly seasonality of a series of positive numbers (e.g. active cases), one per day from a first day of the week:
+the factor of each day of the week is the geometric mean of the ratios of its numbers to their centred geometric moving average of a week,
+normalized to a geometric mean of 1 over the week (e.g. 0.9 if a day is reported 10% lower than the trend).
+The weeks with zeros are skipped: at least 13 days are needed, for a moving average centred on every day of the week.
+*/
+func Weekly(series []float64, first time.Weekday) (Seasonality, error) {
+	var logs [7]float64
+	var counts [7]int
+	for i := 3; i+3 < len(series); i++ {
+		// the logarithm of the geometric mean, exact for exponential trends
+		var ma float64
+		for _, v := range series[i-3 : i+4] {
+			if v <= 0 {
+				ma = math.NaN()
+				break
+			}
+			ma += math.Log(v) / 7
+		}
+		if math.IsNaN(ma) {
+			continue
+		}
+		d := (int(first) + i) % 7
+		logs[d] += math.Log(series[i]) - ma
+		counts[d]++
+	}
+
+	var s Seasonality
+	var mean float64
+	for d := range logs {
+		if counts[d] == 0 {
+			return s, calc.ErrInsufficientData
+		}
+		logs[d] /= float64(counts[d])
+		mean += logs[d] / 7
+	}
+	for d := range s {
+		s[d] = math.Exp(logs[d] - mean)
+	}
+	return s, nil
+}
+
+// Adjust a series of daily numbers from a first day of the week, dividing every number by the factor of its day.
+func (s Seasonality) Adjust(series []float64, first time.Weekday) []float64 {
+	adjusted := make([]float64, len(series))
+	for i, v := range series {
+		adjusted[i] = v / s[(int(first)+i)%7]
+	}
+	return adjusted
+}
+
+// noise variance of the logarithms, from their second differences:
+// the second differences of a line are zero, and their variance is 6 times the one of the noise.
+func noise(logs []float64) float64 {
+	var sum float64
+	for i := 2; i < len(logs); i++ {
+		d := logs[i] - 2*logs[i-1] + logs[i-2]
+		sum += d * d
+	}
+	if len(logs) < 3 {
+		return 0
+	}
+	return math.Max(sum/float64(len(logs)-2)/6, 1e-12)
+}
+
+// lines are the prefix sums of a series, to fit lines to any range of it.
+type lines struct {
+	x, y, xx, xy, yy []float64
+}
+
+func newLines(y []float64) lines {
+	l := lines{
+		x: make([]float64, len(y)+1), y: make([]float64, len(y)+1),
+		xx: make([]float64, len(y)+1), xy: make([]float64, len(y)+1), yy: make([]float64, len(y)+1),
+	}
+	for i, v := range y {
+		t := float64(i)
+		l.x[i+1] = l.x[i] + t
+		l.y[i+1] = l.y[i] + v
+		l.xx[i+1] = l.xx[i] + t*t
+		l.xy[i+1] = l.xy[i] + t*v
+		l.yy[i+1] = l.yy[i] + v*v
+	}
+	return l
+}
+
+// sse is the sum of the squared errors of the least squares line of the numbers from index i to j included.
+func (l lines) sse(i, j int) float64 {
+	n := float64(j - i + 1)
+	sx, sy := l.x[j+1]-l.x[i], l.y[j+1]-l.y[i]
+	sxx := l.xx[j+1] - l.xx[i] - sx*sx/n
+	sxy := l.xy[j+1] - l.xy[i] - sx*sy/n
+	syy := l.yy[j+1] - l.yy[i] - sy*sy/n
+	if sxx <= 0 {
+		return math.Max(0, syy)
+	}
+	return math.Max(0, syy-sxy*sxy/sxx)
+}
@@ -0,0 +1,124 @@
+package analysis_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/calc/analysis"
+)
+
+func TestBreaks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	series := []float64{}
+	n := 100.0
+	for i := 0; i < 60; i++ {
+		r := 1.2
+		switch {
+		case i >= 40:
+			r = 0.95
+		case i >= 20:
+			r = 1.05
+		}
+		n *= r
+		series = append(series, n*math.Exp(rnd.NormFloat64()*0.02))
+	}
+
+	segments, err := analysis.Breaks(series, 5)
+	require.NoError(t, err)
+	require.Len(t, segments, 3, "segments")
+	assert.InDelta(t, 20, segments[1].From, 1, "first break")
+	assert.InDelta(t, 40, segments[2].From, 1, "second break")
+	assert.Equal(t, 0, segments[0].From, "first segment")
+	assert.Equal(t, 59, segments[2].To, "last segment")
+	for i, r := range []float64{1.2, 1.05, 0.95} {
+		assert.InDelta(t, r, segments[i].Rate, 0.01, "rate of segment %d", i)
+	}
+
+	// no breaks at a constant rate
+	series = series[:0]
+	for i := 0; i < 30; i++ {
+		series = append(series, 100*math.Pow(1.1, float64(i))*math.Exp(rnd.NormFloat64()*0.02))
+	}
+	segments, err = analysis.Breaks(series, 5)
+	require.NoError(t, err)
+	assert.Len(t, segments, 1, "constant rate")
+
+	_, err = analysis.Breaks([]float64{1, 0, 2, 3, 4, 5}, 2)
+	assert.Equal(t, calc.ErrInsufficientData, err, "zeros")
+}
+
+func TestWaves(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	wave := func(i, peak, width float64) float64 {
+		return math.Exp(-(i - peak) * (i - peak) / (2 * width * width))
+	}
+	series := []float64{}
+	for i := 0.0; i < 150; i++ {
+		n := 1000*wave(i, 30, 10) + 600*wave(i, 100, 15) + 50
+		series = append(series, n*math.Exp(rnd.NormFloat64()*0.02))
+	}
+
+	waves, err := analysis.Waves(series, 0.1)
+	require.NoError(t, err)
+	require.Len(t, waves, 2, "waves")
+	assert.Equal(t, 0, waves[0].Start, "first start")
+	assert.InDelta(t, 30, waves[0].Peak, 3, "first peak")
+	assert.InDelta(t, 65, waves[0].End, 10, "trough")
+	assert.Equal(t, waves[0].End, waves[1].Start, "second start")
+	assert.InDelta(t, 100, waves[1].Peak, 5, "second peak")
+	assert.InDelta(t, 149, waves[1].End, 5, "second end")
+
+	waves, err = analysis.Waves(series[:90], 0.1)
+	require.NoError(t, err)
+	require.Len(t, waves, 2, "growing wave")
+	assert.Equal(t, 89, waves[1].End, "growing wave")
+	assert.True(t, waves[1].Peak > 80, "growing wave")
+
+	waves, err = analysis.Waves(series, 0.9)
+	require.NoError(t, err)
+	assert.Len(t, waves, 1, "higher prominence")
+
+	_, err = analysis.Waves([]float64{5, 5, 5, 5}, 0.1)
+	assert.Equal(t, calc.ErrInsufficientData, err, "flat")
+	_, err = analysis.Waves([]float64{1, -1, 2}, 0.1)
+	assert.Equal(t, calc.ErrNegative, err, "negative")
+}
+
+func TestWeekly(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	factors := analysis.Seasonality{0.7, 1.3, 1.1, 1, 1, 1, 0.8}
+	series, trend := []float64{}, []float64{}
+	first := time.Wednesday
+	for i := 0; i < 56; i++ {
+		n := 100 * math.Pow(1.1, float64(i))
+		trend = append(trend, n)
+		series = append(series, n*factors[(int(first)+i)%7]*math.Exp(rnd.NormFloat64()*0.01))
+	}
+
+	s, err := analysis.Weekly(series, first)
+	require.NoError(t, err)
+	product := 1.0
+	for d := range factors {
+		product *= factors[d]
+	}
+	for d := range factors {
+		assert.InDelta(t, factors[d]/math.Pow(product, 1.0/7), s[d], 0.02, "factor of %s", time.Weekday(d))
+	}
+
+	adjusted := s.Adjust(series, first)
+	for i := 7; i < len(series); i++ {
+		r, err := calc.Rate(adjusted[i-7], adjusted[i], 7)
+		require.NoError(t, err)
+		assert.InDelta(t, 1.1, r, 0.01, "adjusted rate of day %d", i)
+		assert.InDelta(t, 1, adjusted[i]/trend[i]/math.Pow(product, 1.0/7), 0.05, "adjusted day %d", i)
+	}
+
+	_, err = analysis.Weekly(series[:9], first)
+	assert.Equal(t, calc.ErrInsufficientData, err, "short series")
+}
@@ -1,24 +1,244 @@
 package calc
 
-import "math"
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrInsufficientData is returned when the numbers can't give a result (e.g. zero past cases, or a null period).
+	ErrInsufficientData = errors.New("calc: insufficient data")
+
+	// ErrNegative is returned when numbers that can't be negative (e.g. cases and rates) are negative.
+	ErrNegative = errors.New("calc: negative number")
+
+	// ErrUnitRate is returned when a period can't be computed because the rate is 1 (no growth).
+	ErrUnitRate = errors.New("calc: unit rate")
+
+	// ErrUnreachable is returned when a final number can't be reached at the given rates.
+	ErrUnreachable = errors.New("calc: unreachable number")
+)
 
 // Rate at which a current number is growing, coming from a past number in a given period (days, weeks, years, ...).
-func Rate(past, current, period float64) float64 {
-	return math.Pow(current/past, 1/period)
+func Rate(past, current, period float64) (float64, error) {
+	switch {
+	case past < 0 || current < 0:
+		return 0, ErrNegative
+	case past == 0 || period <= 0:
+		return 0, ErrInsufficientData
+	}
+	return math.Pow(current/past, 1/period), nil
 }
 
 // Forecast of how much a current number will grow at a given rate after a certain period (days, weeks, years, ...).
-func Forecast(current, rate, period float64) float64 {
-	return current * math.Pow(rate, period)
+func Forecast(current, rate, period float64) (float64, error) {
+	if current < 0 || rate < 0 {
+		return 0, ErrNegative
+	}
+	return current * math.Pow(rate, period), nil
 }
 
 // Period (days, weeks, years, ...) that a current number will take to reach a final number at a given growth rate.
-func Period(current, final, rate float64) float64 {
-	return math.Log(final/current) / math.Log(rate)
+func Period(current, final, rate float64) (float64, error) {
+	switch {
+	case current < 0 || final < 0 || rate < 0:
+		return 0, ErrNegative
+	case current == 0 || rate == 0:
+		return 0, ErrInsufficientData
+	case final == current:
+		return 0, nil
+	case final == 0:
+		return 0, ErrUnreachable
+	case rate == 1:
+		return 0, ErrUnitRate
+	}
+	p := math.Log(final/current) / math.Log(rate)
+	if p < 0 {
+		return 0, ErrUnreachable
+	}
+	return p, nil
 }
 
 // Forecast3D it's like Forecast but the rate varies at its own rate (rateOfRate).
-func Forecast3D(current, rate, rateOfRate, period float64) float64 {
+func Forecast3D(current, rate, rateOfRate, period float64) (float64, error) {
+	if current < 0 || rate < 0 || rateOfRate < 0 {
+		return 0, ErrNegative
+	}
 	return current * math.Pow(rate, period) *
-		math.Pow(rateOfRate, (period*period+period)/2)
+		math.Pow(rateOfRate, (period*period+period)/2), nil
+}
+
+/*
+DoublingTime is the period (days, weeks, years, ...) that a number takes to double at a given growth rate,
+or to halve when the rate is lower than 1, while the rate varies at its own rate (rateOfRate, 1 for a constant rate),
+like in Forecast3D.
+*/
+func DoublingTime(rate, rateOfRate float64) (float64, error) {
+	switch {
+	case rate < 0 || rateOfRate < 0:
+		return 0, ErrNegative
+	case rate == 0 || rateOfRate == 0:
+		return 0, ErrInsufficientData
+	case rate == 1:
+		return 0, ErrUnitRate
+	}
+	f := math.Ln2
+	if rate < 1 {
+		f = -f
+	}
+	// log(Forecast3D(1, rate, rateOfRate, t)) = f, i.e. a*t^2 + b*t - f = 0
+	a := math.Log(rateOfRate) / 2
+	b := math.Log(rate) + a
+	if a == 0 {
+		return f / b, nil
+	}
+	d := b*b + 4*a*f
+	if d < 0 {
+		return 0, ErrUnreachable
+	}
+	t := math.Inf(1)
+	for _, root := range []float64{(-b - math.Sqrt(d)) / (2 * a), (-b + math.Sqrt(d)) / (2 * a)} {
+		if root > 0 && root < t {
+			t = root
+		}
+	}
+	if math.IsInf(t, 1) {
+		return 0, ErrUnreachable
+	}
+	return t, nil
+}
+
+// Fit of the rate at which a series of numbers is growing.
+type Fit struct {
+	// Rate of growth per period.
+	Rate float64
+
+	// StdErr is the standard error of the logarithm of Rate.
+	StdErr float64
+
+	// R2 is the coefficient of determination of the fit (1 is a perfect fit).
+	R2 float64
+}
+
+/*
+RateFit fits the rate at which a series of numbers, one per period, is growing,
+by least squares of the logarithms of the numbers (log-linear regression) over the whole series;
+numbers that aren't positive are ignored, but at least 2 are needed.
+If weights is not nil, the least squares are weighted
+(e.g. with the numbers themselves as weights, when they are counts with Poisson noise).
+The standard error is NaN with only 2 numbers.
+*/
+func RateFit(series, weights []float64) (Fit, error) {
+	if weights != nil && len(weights) != len(series) {
+		return Fit{}, ErrInsufficientData
+	}
+	for _, w := range weights {
+		if w < 0 {
+			return Fit{}, ErrNegative
+		}
+	}
+
+	var sw, sx, sy float64
+	n := 0
+	w := func(i int) float64 {
+		if series[i] <= 0 {
+			return 0
+		}
+		if weights == nil {
+			return 1
+		}
+		return weights[i]
+	}
+	for i, v := range series {
+		if w(i) <= 0 {
+			continue
+		}
+		n++
+		sw += w(i)
+		sx += w(i) * float64(i)
+		sy += w(i) * math.Log(v)
+	}
+	if n < 2 {
+		return Fit{}, ErrInsufficientData
+	}
+	mx, my := sx/sw, sy/sw
+
+	var sxx, sxy, syy float64
+	for i, v := range series {
+		if w(i) <= 0 {
+			continue
+		}
+		dx, dy := float64(i)-mx, math.Log(v)-my
+		sxx += w(i) * dx * dx
+		sxy += w(i) * dx * dy
+		syy += w(i) * dy * dy
+	}
+	if sxx == 0 {
+		return Fit{}, ErrInsufficientData
+	}
+	b := sxy / sxx
+
+	var sse float64
+	for i, v := range series {
+		if w(i) <= 0 {
+			continue
+		}
+		e := math.Log(v) - my - b*(float64(i)-mx)
+		sse += w(i) * e * e
+	}
+
+	f := Fit{Rate: math.Exp(b), StdErr: math.NaN(), R2: 1}
+	if n > 2 {
+		f.StdErr = math.Sqrt(sse / float64(n-2) / sxx)
+	}
+	if syy > 0 {
+		f.R2 = 1 - sse/syy
+	}
+	return f, nil
+}
+
+// RateStdErr is the standard error of the logarithm of Rate(past, current, period),
+// when past and current are counts with Poisson noise.
+func RateStdErr(past, current, period float64) float64 {
+	return math.Sqrt(1/past+1/current) / period
+}
+
+/*
+RateOfRatesStdErr is the standard error of the logarithm of the rate of rates Rate(comparisonRate, rate, period),
+from the standard errors of the logarithms of the two rates.
+The rates are assumed independent: when they are computed from overlapping periods,
+they are positively correlated and the standard error is overestimated.
+*/
+func RateOfRatesStdErr(rateStdErr, comparisonStdErr, period float64) float64 {
+	return math.Sqrt(rateStdErr*rateStdErr+comparisonStdErr*comparisonStdErr) / period
+}
+
+// Interval of a rate within z standard errors of its logarithm (e.g. 1.96 for the 95% confidence interval).
+func Interval(rate, stdErr, z float64) (low, high float64) {
+	return rate * math.Exp(-z*stdErr), rate * math.Exp(z*stdErr)
+}
+
+// Z score of a two-sided confidence level of the normal distribution (e.g. 1.96 for 0.95).
+func Z(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+// RollingMean of a series of numbers over a window of periods:
+// the mean i is the one of the window ending at the number i+periods-1 of the series.
+func RollingMean(series []float64, periods int) ([]float64, error) {
+	if periods <= 0 || len(series) < periods {
+		return nil, ErrInsufficientData
+	}
+	means := make([]float64, len(series)-periods+1)
+	var sum float64
+	for i, v := range series {
+		sum += v
+		if i >= periods {
+			sum -= series[i-periods]
+		}
+		if i >= periods-1 {
+			means[i-periods+1] = sum / float64(periods)
+		}
+	}
+	return means, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jsidew/covid/pkg/calc"
 )
@@ -34,12 +35,49 @@ func Test(t *testing.T) {
 		past, current float64 = 12543, 35456
 		per1, per2    float64 = 13, 21
 	)
-	rate := calc.Rate(past, current, per1)
-	final := calc.Forecast(current, rate, per2)
-	assert.Equal(t, rate, calc.Rate(current, final, per2))
-	assert.Equal(t, current, math.Round(calc.Forecast(past, rate, per1)))
-	assert.Equal(t, per1, math.Round(calc.Period(past, current, rate)))
-	assert.Equal(t, per2, math.Round(calc.Period(current, final, rate)))
+	rate := must(t)(calc.Rate(past, current, per1))
+	final := must(t)(calc.Forecast(current, rate, per2))
+	assert.Equal(t, rate, must(t)(calc.Rate(current, final, per2)))
+	assert.Equal(t, current, math.Round(must(t)(calc.Forecast(past, rate, per1))))
+	assert.Equal(t, per1, math.Round(must(t)(calc.Period(past, current, rate))))
+	assert.Equal(t, per2, math.Round(must(t)(calc.Period(current, final, rate))))
+}
+
+func TestErrors(t *testing.T) {
+	for i, test := range []struct {
+		f   func() (float64, error)
+		err error
+	}{
+		{func() (float64, error) { return calc.Rate(0, 123, 20) }, calc.ErrInsufficientData},
+		{func() (float64, error) { return calc.Rate(123, 1234, 0) }, calc.ErrInsufficientData},
+		{func() (float64, error) { return calc.Rate(-4, 12, 34) }, calc.ErrNegative},
+		{func() (float64, error) { return calc.Rate(4, -12, 34) }, calc.ErrNegative},
+		{func() (float64, error) { return calc.Forecast(-4, 1.1, 34) }, calc.ErrNegative},
+		{func() (float64, error) { return calc.Period(1234, 1, 1) }, calc.ErrUnitRate},
+		{func() (float64, error) { return calc.Period(1234, 1, 1.1) }, calc.ErrUnreachable},
+		{func() (float64, error) { return calc.Period(1, 1234, 0.9) }, calc.ErrUnreachable},
+		{func() (float64, error) { return calc.Period(1234, 0, 0.9) }, calc.ErrUnreachable},
+		{func() (float64, error) { return calc.Period(0, 1234, 1.1) }, calc.ErrInsufficientData},
+		{func() (float64, error) { return calc.Period(12, 4, -0.9) }, calc.ErrNegative},
+		{func() (float64, error) { return calc.Forecast3D(4, 1.1, -0.9, 3) }, calc.ErrNegative},
+	} {
+		_, err := test.f()
+		assert.Equal(t, test.err, err, "test %d", i)
+	}
+
+	r, err := calc.Rate(123, 0, 20)
+	assert.NoError(t, err, "rate to zero")
+	assert.Equal(t, 0.0, r, "rate to zero")
+	p, err := calc.Period(123, 123, 1)
+	assert.NoError(t, err, "period to same number")
+	assert.Equal(t, 0.0, p, "period to same number")
+
+	_, err = calc.RateFit([]float64{0, 100}, nil)
+	assert.Equal(t, calc.ErrInsufficientData, err, "fit with one number")
+	_, err = calc.RateFit([]float64{100, 110}, []float64{1})
+	assert.Equal(t, calc.ErrInsufficientData, err, "fit with less weights")
+	_, err = calc.RateFit([]float64{100, 110}, []float64{1, -1})
+	assert.Equal(t, calc.ErrNegative, err, "fit with negative weights")
 }
 
 func TestForecast3D(t *testing.T) {
@@ -52,10 +90,147 @@ func TestForecast3D(t *testing.T) {
 		n *= r3
 	}
 
-	f := calc.Forecast3D(a, r, r2, p)
+	f := must(t)(calc.Forecast3D(a, r, r2, p))
 	assert.Equal(t, fmt.Sprintf("%.4f", n), fmt.Sprintf("%.4f", f))
 }
 
+func TestDoublingTime(t *testing.T) {
+	assert.InDelta(t, 7, must(t)(calc.DoublingTime(math.Pow(2, 1.0/7), 1)), 1e-9, "doubling")
+	assert.InDelta(t, 12, must(t)(calc.DoublingTime(math.Pow(0.5, 1.0/12), 1)), 1e-9, "halving")
+
+	var r, x float64 = 1.11, 0.995
+	d := must(t)(calc.DoublingTime(r, x))
+	assert.InDelta(t, 2, must(t)(calc.Forecast3D(1, r, x, d)), 1e-9, "doubling at a varying rate")
+	assert.True(t, d > must(t)(calc.DoublingTime(r, 1)), "slower doubling when the rate drops")
+	d = must(t)(calc.DoublingTime(0.95, 1.0002))
+	assert.InDelta(t, 0.5, must(t)(calc.Forecast3D(1, 0.95, 1.0002, d)), 1e-9, "halving at a varying rate")
+
+	_, err := calc.DoublingTime(1, 1)
+	assert.Equal(t, calc.ErrUnitRate, err, "unit rate")
+	_, err = calc.DoublingTime(1.01, 0.9)
+	assert.Equal(t, calc.ErrUnreachable, err, "peak before doubling")
+}
+
+func TestFitCurve(t *testing.T) {
+	for _, curve := range []calc.Curve{calc.Logistic, calc.Gompertz} {
+		want := calc.CurveFit{Curve: curve, K: 10000, R: 0.25, T: 30}
+		series := []float64{}
+		for i := 0; i < 45; i++ {
+			series = append(series, math.Round(want.At(float64(i))))
+		}
+		f, err := calc.FitCurve(curve, series)
+		require.NoError(t, err, "%s", curve)
+		assert.InDelta(t, want.K, f.K, 10, "%s final size", curve)
+		assert.InDelta(t, want.R, f.R, 0.001, "%s rate", curve)
+		assert.InDelta(t, want.T, f.T, 0.1, "%s inflection", curve)
+		assert.InDelta(t, 1, f.R2, 1e-6, "%s R2", curve)
+	}
+
+	_, err := calc.FitCurve(calc.Logistic, []float64{1, 2, 3})
+	assert.Equal(t, calc.ErrInsufficientData, err, "short series")
+	_, err = calc.FitCurve(calc.Logistic, []float64{1, 2, -3, 4})
+	assert.Equal(t, calc.ErrNegative, err, "negative numbers")
+	_, err = calc.FitCurve("richards", []float64{1, 2, 3, 4})
+	assert.Equal(t, calc.ErrUnknownCurve, err, "unknown curve")
+}
+
+func TestRateFit(t *testing.T) {
+	var a, r float64 = 735, 1.11
+
+	series := []float64{}
+	for i := 0; i < 10; i++ {
+		series = append(series, must(t)(calc.Forecast(a, r, float64(i))))
+	}
+	f := fit(t)(calc.RateFit(series, nil))
+	assert.InDelta(t, r, f.Rate, 1e-9, "rate")
+	assert.InDelta(t, 0, f.StdErr, 1e-9, "standard error")
+	assert.InDelta(t, 1, f.R2, 1e-9, "R2")
+
+	// one bad reporting day at the end
+	series[len(series)-1] /= 2
+	two := must(t)(calc.Rate(series[0], series[len(series)-1], float64(len(series)-1)))
+	f = fit(t)(calc.RateFit(series, nil))
+	assert.True(t, math.Abs(f.Rate-r) < math.Abs(two-r), "fit more robust than two points")
+	assert.True(t, f.StdErr > 0, "standard error")
+	assert.True(t, f.R2 < 1, "R2")
+
+	wf := fit(t)(calc.RateFit(series, series))
+	assert.NotEqual(t, f.Rate, wf.Rate, "weighted rate")
+
+	// non positive numbers are ignored
+	f = fit(t)(calc.RateFit([]float64{0, 100, 110, -3, 133.1}, nil))
+	assert.InDelta(t, 1.1, f.Rate, 1e-9, "rate with zeros")
+
+	f = fit(t)(calc.RateFit([]float64{100, 110}, nil))
+	assert.True(t, math.IsNaN(f.StdErr), "standard error with two numbers")
+}
+
+func TestInterval(t *testing.T) {
+	assert.InDelta(t, 1.96, calc.Z(0.95), 0.001, "z score")
+	assert.InDelta(t, 2.576, calc.Z(0.99), 0.001, "z score")
+
+	se := calc.RateStdErr(100, 400, 7)
+	assert.InDelta(t, math.Sqrt(0.0125)/7, se, 1e-12, "standard error")
+	assert.True(t, calc.RateStdErr(10000, 40000, 7) < se, "standard error with more cases")
+
+	r := must(t)(calc.Rate(100, 400, 7))
+	low, high := calc.Interval(r, se, calc.Z(0.95))
+	assert.True(t, low < r && r < high, "interval")
+	assert.InDelta(t, r*r, low*high, 1e-12, "symmetric logarithms")
+
+	assert.InDelta(t, 0.5/7, calc.RateOfRatesStdErr(0.3, 0.4, 7), 1e-12, "rate of rates standard error")
+}
+
+func TestRollingMean(t *testing.T) {
+	means, err := calc.RollingMean([]float64{1, 2, 3, 4, 5, 6}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{2, 3, 4, 5}, means)
+
+	means, err = calc.RollingMean([]float64{1, 2}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1.5}, means, "one window")
+
+	_, err = calc.RollingMean([]float64{1, 2}, 3)
+	assert.Equal(t, calc.ErrInsufficientData, err, "shorter series")
+}
+
+func TestReproduction(t *testing.T) {
+	si, err := calc.SerialInterval(4.7, 2.9)
+	require.NoError(t, err)
+	var sum, mean float64
+	for i, w := range si {
+		sum += w
+		mean += float64(i) * w
+	}
+	assert.InDelta(t, 1, sum, 1e-12, "distribution")
+	assert.InDelta(t, 4.7, mean, 0.1, "mean serial interval")
+
+	for _, r := range []float64{0.9, 1, 1.15} {
+		incidence := []float64{}
+		for i := 0; i < 40; i++ {
+			incidence = append(incidence, must(t)(calc.Forecast(100000, r, float64(i))))
+		}
+		rts, err := calc.Reproduction(incidence, si, 7)
+		require.NoError(t, err)
+		assert.Len(t, rts, 33)
+		rt := rts[len(rts)-1]
+		assert.InDelta(t, must(t)(calc.GrowthToR(r, si)), rt.R, 0.01, "Rt at rate %.2f", r)
+		assert.True(t, rt.StdErr > 0 && rt.StdErr < 0.01, "standard error at rate %.2f", r)
+	}
+	assert.InDelta(t, 1, must(t)(calc.GrowthToR(1, si)), 1e-12, "R at unit rate")
+
+	rts, err := calc.Reproduction([]float64{0, 0, 0, 5}, si, 1)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(rts[0].R), "no infectors")
+
+	_, err = calc.Reproduction([]float64{1, 2, 3}, si, 3)
+	assert.Equal(t, calc.ErrInsufficientData, err, "window too long")
+	_, err = calc.Reproduction([]float64{1, -2, 3}, si, 1)
+	assert.Equal(t, calc.ErrNegative, err, "negative incidence")
+	_, err = calc.SerialInterval(0, 1)
+	assert.Equal(t, calc.ErrInsufficientData, err, "null serial interval")
+}
+
 /*
 The following tests don't check assertions.
 They just print the output of the tested functions.
@@ -64,23 +239,37 @@ They just print the output of the tested functions.
 func TestRate(t *testing.T) {
 	t.Log("past, current, period")
 	for i, test := range table {
-		r := calc.Rate(test.first, test.second, test.third)
-		t.Logf("%d: %.2f, %.2f, %.2f = %f", i, test.first, test.second, test.third, r)
+		r, err := calc.Rate(test.first, test.second, test.third)
+		t.Logf("%d: %.2f, %.2f, %.2f = %f (%v)", i, test.first, test.second, test.third, r, err)
 	}
 }
 
 func TestForecast(t *testing.T) {
 	t.Log("current, rate, period")
 	for i, test := range table {
-		f := calc.Forecast(test.first, test.second, test.third)
-		t.Logf("%d: %.2f, %.2f, %.2f = %f", i, test.first, test.second, test.third, f)
+		f, err := calc.Forecast(test.first, test.second, test.third)
+		t.Logf("%d: %.2f, %.2f, %.2f = %f (%v)", i, test.first, test.second, test.third, f, err)
 	}
 }
 
 func TestPeriod(t *testing.T) {
 	t.Log("current, final, rate")
 	for i, test := range table {
-		d := calc.Period(test.first, test.second, test.third)
-		t.Logf("%d: %.2f, %.2f, %.2f = %f", i, test.first, test.second, test.third, d)
+		d, err := calc.Period(test.first, test.second, test.third)
+		t.Logf("%d: %.2f, %.2f, %.2f = %f (%v)", i, test.first, test.second, test.third, d, err)
+	}
+}
+
+func must(t *testing.T) func(float64, error) float64 {
+	return func(f float64, err error) float64 {
+		require.NoError(t, err)
+		return f
+	}
+}
+
+func fit(t *testing.T) func(calc.Fit, error) calc.Fit {
+	return func(f calc.Fit, err error) calc.Fit {
+		require.NoError(t, err)
+		return f
 	}
 }
@@ -0,0 +1,187 @@
+package calc
+
+import (
+	"errors"
+	"math"
+)
+
+// Curves of growth of cumulative numbers, to a final size.
+const (
+	// Logistic curve K/(1+exp(-R(t-T))), symmetric around the inflection.
+	Logistic Curve = "logistic"
+
+	// Gompertz curve K*exp(-exp(-R(t-T))), with a slower approach to the final size than the logistic.
+	Gompertz Curve = "gompertz"
+)
+
+var (
+	// ErrNoConvergence is returned when a fit doesn't converge to a valid result.
+	ErrNoConvergence = errors.New("calc: no convergence")
+
+	// ErrUnknownCurve is returned when fitting a curve other than Logistic and Gompertz.
+	ErrUnknownCurve = errors.New("calc: unknown curve")
+)
+
+// Curve of growth.
+type Curve string
+
+// CurveFit of a curve to a series of cumulative numbers.
+type CurveFit struct {
+	Curve Curve
+
+	// K is the final size.
+	K float64
+
+	// R is the rate of growth per period, at the inflection.
+	R float64
+
+	// T is the period of the inflection, from the first number of the series.
+	T float64
+
+	// R2 is the coefficient of determination of the fit (1 is a perfect fit).
+	R2 float64
+}
+
+// At gives the value of the fitted curve at a period from the first number of the series.
+func (f CurveFit) At(t float64) float64 {
+	y, _ := f.Curve.eval(f.K, f.R, f.T, t)
+	return y
+}
+
+/*
+FitCurve fits a curve to a series of cumulative numbers, one per period,
+by nonlinear least squares (Levenberg-Marquardt method):
+at least 4 numbers are needed.
+*/
+func FitCurve(curve Curve, series []float64) (CurveFit, error) {
+	if curve != Logistic && curve != Gompertz {
+		return CurveFit{}, ErrUnknownCurve
+	}
+	if len(series) < 4 {
+		return CurveFit{}, ErrInsufficientData
+	}
+	var max, mean float64
+	steepest, step := 0, 0.0
+	for i, v := range series {
+		if v < 0 {
+			return CurveFit{}, ErrNegative
+		}
+		max = math.Max(max, v)
+		mean += v / float64(len(series))
+		if i > 0 && v-series[i-1] > step {
+			steepest, step = i, v-series[i-1]
+		}
+	}
+	if max == 0 {
+		return CurveFit{}, ErrInsufficientData
+	}
+
+	// scaled numbers, for the numerical stability of the final size
+	y := make([]float64, len(series))
+	for i, v := range series {
+		y[i] = v / max
+	}
+	p := [3]float64{2, 0.2, float64(steepest)}
+	sse := func(p [3]float64) float64 {
+		var s float64
+		for i, v := range y {
+			f, _ := curve.eval(p[0], p[1], p[2], float64(i))
+			s += (v - f) * (v - f)
+		}
+		return s
+	}
+
+	lambda, cost := 1e-3, sse(p)
+	for iter := 0; iter < 500; iter++ {
+		// normal equations (J'J + lambda diag(J'J)) delta = J'r
+		var a [3][3]float64
+		var g [3]float64
+		for i, v := range y {
+			f, d := curve.eval(p[0], p[1], p[2], float64(i))
+			for j := 0; j < 3; j++ {
+				g[j] += d[j] * (v - f)
+				for k := 0; k < 3; k++ {
+					a[j][k] += d[j] * d[k]
+				}
+			}
+		}
+		for j := 0; j < 3; j++ {
+			a[j][j] *= 1 + lambda
+		}
+		delta, ok := solve3(a, g)
+		if !ok {
+			break
+		}
+		q := [3]float64{p[0] + delta[0], p[1] + delta[1], p[2] + delta[2]}
+		if e := sse(q); e < cost && q[0] > 0 && q[1] > 0 {
+			done := cost-e < 1e-12*cost
+			p, cost, lambda = q, e, lambda/10
+			if done {
+				break
+			}
+		} else {
+			lambda *= 10
+			if lambda > 1e12 {
+				break
+			}
+		}
+	}
+	if math.IsNaN(cost) || p[0] <= 0 || p[1] <= 0 {
+		return CurveFit{}, ErrNoConvergence
+	}
+
+	var sst float64
+	for _, v := range series {
+		sst += (v/max - mean/max) * (v/max - mean/max)
+	}
+	fit := CurveFit{Curve: curve, K: p[0] * max, R: p[1], T: p[2], R2: 1}
+	if sst > 0 {
+		fit.R2 = 1 - cost/sst
+	}
+	return fit, nil
+}
+
+// eval the curve with final size k, rate r and inflection at tt, at period t,
+// with its derivatives by k, r and tt.
+func (c Curve) eval(k, r, tt, t float64) (float64, [3]float64) {
+	e := math.Exp(-r * (t - tt))
+	if c == Gompertz {
+		f := math.Exp(-e)
+		return k * f, [3]float64{f, k * f * e * (t - tt), -k * f * e * r}
+	}
+	f := 1 / (1 + e)
+	return k * f, [3]float64{f, k * f * f * e * (t - tt), -k * f * f * e * r}
+}
+
+// solve3 solves the linear system a x = b, by Gaussian elimination with partial pivoting.
+func solve3(a [3][3]float64, b [3]float64) ([3]float64, bool) {
+	for i := 0; i < 3; i++ {
+		pivot := i
+		for j := i + 1; j < 3; j++ {
+			if math.Abs(a[j][i]) > math.Abs(a[pivot][i]) {
+				pivot = j
+			}
+		}
+		if a[pivot][i] == 0 {
+			return b, false
+		}
+		a[i], a[pivot] = a[pivot], a[i]
+		b[i], b[pivot] = b[pivot], b[i]
+		for j := i + 1; j < 3; j++ {
+			m := a[j][i] / a[i][i]
+			for k := i; k < 3; k++ {
+				a[j][k] -= m * a[i][k]
+			}
+			b[j] -= m * b[i]
+		}
+	}
+	var x [3]float64
+	for i := 2; i >= 0; i-- {
+		x[i] = b[i]
+		for k := i + 1; k < 3; k++ {
+			x[i] -= a[i][k] * x[k]
+		}
+		x[i] /= a[i][i]
+	}
+	return x, true
+}
@@ -0,0 +1,111 @@
+package calc
+
+import "math"
+
+// Gamma prior of the reproduction number in Reproduction, with mean 5 and standard deviation 5.
+const (
+	priorShape = 1
+	priorScale = 5
+)
+
+// Rt is an estimate of the effective reproduction number:
+// the average number of people infected by each infected person.
+type Rt struct {
+	// R is the reproduction number.
+	R float64
+
+	// StdErr is the standard error of the logarithm of R.
+	StdErr float64
+}
+
+/*
+SerialInterval is the discrete distribution of the days between the symptoms of an infected person
+and the symptoms of the people they infect, as a gamma distribution with a given mean and standard deviation
+(e.g. 4.7 and 2.9 days for COVID-19).
+The element i is the probability of a serial interval of i days: the element 0 is always 0,
+and the last element is the last day within 3 standard deviations from the mean.
+*/
+func SerialInterval(mean, stdDev float64) ([]float64, error) {
+	switch {
+	case mean < 0 || stdDev < 0:
+		return nil, ErrNegative
+	case mean == 0 || stdDev == 0:
+		return nil, ErrInsufficientData
+	}
+	shape, scale := mean*mean/(stdDev*stdDev), stdDev*stdDev/mean
+	w := make([]float64, int(math.Ceil(mean+3*stdDev))+1)
+	var sum float64
+	for i := 1; i < len(w); i++ {
+		w[i] = math.Exp((shape-1)*math.Log(float64(i)) - float64(i)/scale)
+		sum += w[i]
+	}
+	for i := range w {
+		w[i] /= sum
+	}
+	return w, nil
+}
+
+/*
+Reproduction numbers from a series of daily new cases (incidence),
+estimated with the method of Cori et al. (2013) on windows of a given number of days,
+for the serial interval distribution si (see SerialInterval).
+The element i of the result is the estimate for the window ending on the day window+i of the incidence,
+so that the first days are only used as infectors.
+R is NaN for windows without cases that could infect.
+*/
+func Reproduction(incidence, si []float64, window int) ([]Rt, error) {
+	switch {
+	case window < 1 || len(incidence) <= window || len(si) < 2:
+		return nil, ErrInsufficientData
+	}
+	for _, n := range incidence {
+		if n < 0 {
+			return nil, ErrNegative
+		}
+	}
+
+	// infectiousness of the infected of the previous days
+	infectious := make([]float64, len(incidence))
+	for t := range incidence {
+		for s := 1; s < len(si) && s <= t; s++ {
+			infectious[t] += incidence[t-s] * si[s]
+		}
+	}
+
+	rts := make([]Rt, len(incidence)-window)
+	for i := range rts {
+		var cases, lambda float64
+		for t := i + 1; t <= i+window; t++ {
+			cases += incidence[t]
+			lambda += infectious[t]
+		}
+		if lambda == 0 {
+			rts[i] = Rt{R: math.NaN(), StdErr: math.NaN()}
+			continue
+		}
+		shape := priorShape + cases
+		rts[i] = Rt{
+			R:      shape / (1/priorScale + lambda),
+			StdErr: 1 / math.Sqrt(shape),
+		}
+	}
+	return rts, nil
+}
+
+/*
+GrowthToR converts the daily growth rate of new cases into the reproduction number,
+for the serial interval distribution si (see SerialInterval), as in Wallinga and Lipsitch (2007).
+*/
+func GrowthToR(rate float64, si []float64) (float64, error) {
+	switch {
+	case rate < 0:
+		return 0, ErrNegative
+	case rate == 0 || len(si) < 2:
+		return 0, ErrInsufficientData
+	}
+	var m float64
+	for s := 1; s < len(si); s++ {
+		m += si[s] * math.Pow(rate, -float64(s))
+	}
+	return 1 / m, nil
+}
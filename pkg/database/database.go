@@ -4,9 +4,11 @@ package database
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/internal/location"
 )
 
 const (
@@ -22,6 +24,21 @@ func init() {
 // EndpointName is a unique name identifying a web ednpoint and its related resource.
 type EndpointName string
 
+// Role of a resource in the computation of active cases (DB.ActiveCases).
+type Role uint8
+
+const (
+	// Total is the role of the resource with all cases (e.g. confirmed cases).
+	Total Role = iota + 1
+
+	// Subtract is the role of the resources with cases to be subtracted
+	// from the Total resource's cases to give active cases (e.g. recovered and dead).
+	Subtract
+
+	// Info is the role of informational resources, not used for active cases.
+	Info
+)
+
 /*
 DB is the database.
 The resources are lazy-loaded from 2 caches, either:
@@ -29,12 +46,20 @@ The resources are lazy-loaded from 2 caches, either:
     2. the file-system, as files saved in the specified directory.
 If the cache period has expired, or the files don't exist already,
 the resources are taken from the web, and then stored in the caches.
+A local DB (NewLocal) only reads the files of its directory.
+Once the resources are set (DB.Set and DB.Derive), the DB is safe for concurrent use.
 */
 type DB struct {
 	origin, cachedir string
+	local            bool
+	mu               sync.RWMutex
 
 	expiration time.Duration
-	first      EndpointName
+	total      EndpointName
+	names      []EndpointName
+	roles      map[EndpointName]Role
+	derived    map[EndpointName]expression
+	estimates  map[string]int
 	resources  resources
 }
 
@@ -49,70 +74,274 @@ func New(origin, cachedir string, cacheExpiration time.Duration) *DB {
 }
 
 /*
-Set a new named endpoint to a web resource.
-The first resource that is set is considered the one with all cases,
-while the next resources are the ones with cases that can be subtracted
-from first one's cases to give active cases (DB.ActiveCases).
+NewLocal database, reading the resources from the CSV files in dir named after them (e.g. "confirmed.csv"),
+like the ones generated by package synth: the files are only read, never refreshed from the web nor created.
+*/
+func NewLocal(dir string) *DB {
+	return &DB{cachedir: dir, local: true}
+}
+
+/*
+Set a new named endpoint to a web resource, with its role.
+Only one resource can have the Total role: setting a new Total resource
+turns the previous one into an Info resource.
+Setting a name already used by a derived series (DB.Derive) replaces the series.
 */
-func (db *DB) Set(n EndpointName, endpoint string) {
+func (db *DB) Set(n EndpointName, endpoint string, role Role) {
 	if db.resources == nil {
 		db.resources = resources{}
+		db.roles = map[EndpointName]Role{}
 	}
 	url := fmt.Sprintf("%s/%s", db.origin, endpoint)
-	db.resources.Set(db.cachedir, string(n), url, db.expiration)
-	if db.first == "" {
-		db.first = n
+	db.resources.Set(db.cachedir, string(n), url, db.expiration, db.local)
+	if _, ok := db.roles[n]; !ok {
+		db.names = append(db.names, n)
+	}
+	delete(db.derived, n)
+	switch {
+	case role == Total && db.total != n:
+		if db.total != "" {
+			db.roles[db.total] = Info
+		}
+		db.total = n
+	case role != Total && db.total == n:
+		db.total = ""
+	}
+	db.roles[n] = role
+}
+
+/*
+Derive a named series from an expression of other series,
+that are either resources (DB.Set) or derived series already defined,
+added or subtracted together (e.g. "confirmed - recovered - dead" or "recovered + dead").
+Names in the expression can't contain the operators + and -,
+and a series can't be derived from itself, even through other derived series.
+Derived series can be queried like resources with DB.Cases.
+*/
+func (db *DB) Derive(n EndpointName, expr string) error {
+	if _, ok := db.roles[n]; ok {
+		return errors.F("name `%s` already set as resource", n)
+	}
+	e, err := parseExpression(expr)
+	if err != nil {
+		return errors.W(err)
+	}
+	for _, t := range e {
+		if t.name == n || db.refers(t.name, n) {
+			return errors.F("series `%s` can't be derived from itself", n)
+		}
+		if !db.has(t.name) {
+			return errors.F("unknown series `%s` in expression `%s`", t.name, expr)
+		}
+	}
+	if db.derived == nil {
+		db.derived = map[EndpointName]expression{}
 	}
+	db.derived[n] = e
+	return nil
 }
 
 // Latest update time.
 func (db *DB) Latest() (time.Time, error) {
-	r, err := db.resources.Get(db.first.String())
+	r, err := db.totalMatrix()
 	if err != nil {
-		return time.Time{}, errors.W(err)
+		return time.Time{}, err
 	}
 	return r.Latest()
 }
 
-// ActiveCases affected, selected by country and time.
-func (db *DB) ActiveCases(country string, t time.Time) (int, error) {
-	r, err := db.resources.Get(db.first.String())
+// First time with data in the resources.
+func (db *DB) First() (time.Time, error) {
+	r, err := db.totalMatrix()
 	if err != nil {
-		return 0, errors.W(err)
+		return time.Time{}, err
 	}
+	return r.First()
+}
 
-	var c int
-	c, err = r.Cases(country, t)
+// Cases of a named series, either a resource or a derived one, selected by country and time.
+// The country can also be a province in the form COUNTRY/PROVINCE (see Place.Location).
+func (db *DB) Cases(n EndpointName, country string, t time.Time) (int, error) {
+	if e, ok := db.derived[n]; ok {
+		var c int
+		for _, term := range e {
+			s, err := db.Cases(term.name, country, t)
+			if err != nil {
+				return 0, err
+			}
+			c += term.sign * s
+		}
+		return c, nil
+	}
+	m, err := db.matrix(n)
+	if err != nil {
+		return 0, errors.W(err)
+	}
+	c, err := m.Cases(country, t)
 	if err != nil {
 		return 0, errors.W(err)
 	}
-	for _, res := range db.resources {
-		if res.Name() == db.first.String() {
+	return c, nil
+}
+
+/*
+Estimate the active cases of a country (empty for the whole world),
+for data sources that don't publish all the closed cases (e.g. recovered):
+the cases older than a resolution period of days are considered closed,
+when they are more than the closed cases reported by the Subtract resources.
+A period of 0 days disables the estimation.
+*/
+func (db *DB) Estimate(country string, days int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.estimates == nil {
+		db.estimates = map[string]int{}
+	}
+	if days <= 0 {
+		delete(db.estimates, location.Key(country))
+		return
+	}
+	db.estimates[location.Key(country)] = days
+}
+
+// Estimated tells if the active cases of a country (empty for the whole world) are estimated (see DB.Estimate).
+func (db *DB) Estimated(country string) bool {
+	_, ok := db.estimate(country)
+	return ok
+}
+
+// ActiveCases affected, selected by country (or COUNTRY/PROVINCE) and time:
+// the cases of the Total resource minus the cases of the Subtract resources
+// (or the estimated closed cases, see DB.Estimate).
+func (db *DB) ActiveCases(country string, t time.Time) (int, error) {
+	if db.total == "" {
+		return 0, errors.F("no resource set as %s", Total)
+	}
+	total, err := db.Cases(db.total, country, t)
+	if err != nil {
+		return 0, err
+	}
+	var closed int
+	for _, n := range db.names {
+		if db.roles[n] != Subtract {
 			continue
 		}
-		m, err := res.Get()
+		s, err := db.Cases(n, country, t)
 		if err != nil {
-			return 0, errors.W(err)
+			return 0, err
 		}
-		s, err := m.Cases(country, t)
+		closed += s
+	}
+	if days, ok := db.estimate(country); ok {
+		old, err := db.Cases(db.total, country, t.AddDate(0, 0, -days))
 		if err != nil {
-			return 0, errors.W(err)
+			return 0, err
+		}
+		if old > closed {
+			closed = old
 		}
-		c -= s
 	}
+	return total - closed, nil
+}
 
-	return c, nil
+// Series of cases of a named series (see DB.Cases), one per day from time from to time to included.
+func (db *DB) Series(n EndpointName, country string, from, to time.Time) ([]int, error) {
+	return series(from, to, func(t time.Time) (int, error) {
+		return db.Cases(n, country, t)
+	})
+}
+
+// ActiveSeries of active cases (see DB.ActiveCases), one per day from time from to time to included.
+func (db *DB) ActiveSeries(country string, from, to time.Time) ([]int, error) {
+	return series(from, to, func(t time.Time) (int, error) {
+		return db.ActiveCases(country, t)
+	})
 }
 
 // Countries listed in the resources, sorted by their name.
 func (db *DB) Countries() ([]string, error) {
-	r, err := db.resources.Get(db.first.String())
+	r, err := db.totalMatrix()
 	if err != nil {
-		return nil, errors.W(err)
+		return nil, err
 	}
 	return r.Countries(), nil
 }
 
+func (db *DB) totalMatrix() (matrix, error) {
+	if db.total == "" {
+		return nil, errors.F("no resource set as %s", Total)
+	}
+	r, err := db.matrix(db.total)
+	if err != nil {
+		return nil, errors.W(err)
+	}
+	return r, nil
+}
+
+// matrix of a resource, loaded once for all concurrent queries:
+// only the first load takes the exclusive lock.
+func (db *DB) matrix(n EndpointName) (matrix, error) {
+	db.mu.RLock()
+	m, ok := db.resources.Loaded(n.String())
+	db.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.resources.Get(n.String())
+}
+
+// estimate of the active cases of a country, with its resolution period (see DB.Estimate).
+func (db *DB) estimate(country string) (int, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	days, ok := db.estimates[location.Key(country)]
+	return days, ok
+}
+
+// refers tells if a derived series refers to a named series, directly or through other derived series.
+func (db *DB) refers(derived, n EndpointName) bool {
+	for _, t := range db.derived[derived] {
+		if t.name == n || db.refers(t.name, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (db *DB) has(n EndpointName) bool {
+	if _, ok := db.roles[n]; ok {
+		return true
+	}
+	_, ok := db.derived[n]
+	return ok
+}
+
+func series(from, to time.Time, cases func(time.Time) (int, error)) ([]int, error) {
+	list := []int{}
+	for t := from; !t.After(to); t = t.AddDate(0, 0, 1) {
+		c, err := cases(t)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
 func (e EndpointName) String() string {
 	return string(e)
 }
+
+func (r Role) String() string {
+	switch r {
+	case Total:
+		return "total"
+	case Subtract:
+		return "subtract"
+	case Info:
+		return "info"
+	}
+	return fmt.Sprintf("role(%d)", r)
+}
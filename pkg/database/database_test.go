@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -38,19 +39,62 @@ func Test(t *testing.T) {
 		desc := fmt.Sprintf("with %s expiration", dur)
 		t.Run(desc, func(t *testing.T) {
 			db := database.New(env.ServerURL(), env.TmpDir(), dur)
-			db.Set("confirmed", "/confirmed.csv")
-			db.Set("recovered", "/recovered.csv")
-			db.Set("dead", "/deaths.csv")
+			db.Set("confirmed", "/confirmed.csv", database.Total)
+			db.Set("recovered", "/recovered.csv", database.Subtract)
+			db.Set("dead", "/deaths.csv", database.Subtract)
+			require.NoError(t, db.Derive("active", "confirmed - recovered - dead"), "derive active")
+			require.NoError(t, db.Derive("closed", "recovered + dead"), "derive closed")
 			t.Run("LatestTime", func(t *testing.T) {
 				latest, err := db.Latest()
 				require.NoError(t, err, "error")
 				assert.Equal(t, date(2020, time.March, 19), latest, "time")
 			})
+			t.Run("FirstTime", func(t *testing.T) {
+				first, err := db.First()
+				require.NoError(t, err, "error")
+				assert.Equal(t, date(2020, time.January, 22), first, "time")
+			})
 			t.Run("ActiveCases", func(t *testing.T) {
 				cases, err := db.ActiveCases("italy", date(2020, time.March, 3))
 				require.NoError(t, err, "error")
 				assert.Equal(t, 2263, cases, "active cases")
 			})
+			t.Run("DerivedCases", func(t *testing.T) {
+				active, err := db.Cases("active", "italy", date(2020, time.March, 3))
+				require.NoError(t, err, "error")
+				assert.Equal(t, 2263, active, "active cases")
+				closed, err := db.Cases("closed", "italy", date(2020, time.March, 3))
+				require.NoError(t, err, "error")
+				confirmed, err := db.Cases("confirmed", "italy", date(2020, time.March, 3))
+				require.NoError(t, err, "error")
+				assert.Equal(t, confirmed, active+closed, "confirmed cases")
+			})
+			t.Run("Series", func(t *testing.T) {
+				from, to := date(2020, time.March, 1), date(2020, time.March, 3)
+				active, err := db.ActiveSeries("italy", from, to)
+				require.NoError(t, err, "error")
+				require.Len(t, active, 3, "days")
+				assert.Equal(t, 2263, active[2], "active cases")
+				confirmed, err := db.Series("confirmed", "italy", from, to)
+				require.NoError(t, err, "error")
+				assert.Equal(t, []int{1694, 2036, 2502}, confirmed, "confirmed cases")
+			})
+			t.Run("Concurrent", func(t *testing.T) {
+				var wg sync.WaitGroup
+				cases := make([]int, 8)
+				for i := range cases {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						db.Estimate(fmt.Sprint("phantom", i), 14)
+						cases[i], _ = db.ActiveCases("italy", date(2020, time.March, 3))
+					}(i)
+				}
+				wg.Wait()
+				for _, c := range cases {
+					assert.Equal(t, 2263, c, "active cases")
+				}
+			})
 			t.Run("Countries", func(t *testing.T) {
 				countries, err := db.Countries()
 				require.NoError(t, err, "error")
@@ -64,6 +108,144 @@ func Test(t *testing.T) {
 	}
 }
 
+func TestRoles(t *testing.T) {
+	defer setup().Teardown()
+
+	db := database.New(env.ServerURL(), env.TmpDir(), time.Second)
+	_, err := db.Latest()
+	assert.EqualError(t, err, "database: no resource set as total", "no total")
+
+	db.Set("dead", "/deaths.csv", database.Subtract)
+	db.Set("confirmed", "/confirmed.csv", database.Total)
+	db.Set("recovered", "/recovered.csv", database.Info)
+	cases, err := db.ActiveCases("italy", date(2020, time.March, 3))
+	require.NoError(t, err, "error")
+	assert.Equal(t, 2263+160, cases, "active cases without recovered")
+
+	db.Set("recovered", "/recovered.csv", database.Subtract)
+	cases, err = db.ActiveCases("italy", date(2020, time.March, 3))
+	require.NoError(t, err, "error")
+	assert.Equal(t, 2263, cases, "active cases")
+}
+
+func TestEstimate(t *testing.T) {
+	defer setup().Teardown()
+
+	db := database.New(env.ServerURL(), env.TmpDir(), time.Second)
+	db.Set("confirmed", "/confirmed.csv", database.Total)
+	db.Set("recovered", "/recovered.csv", database.Subtract)
+	db.Set("dead", "/deaths.csv", database.Subtract)
+
+	now := date(2020, time.March, 19)
+	reported, err := db.ActiveCases("italy", now)
+	require.NoError(t, err, "error")
+
+	db.Estimate("Italy ", 10)
+	assert.True(t, db.Estimated("italy"), "italy estimated")
+	assert.False(t, db.Estimated(""), "world estimated")
+	confirmed, err := db.Cases("confirmed", "italy", now)
+	require.NoError(t, err, "error")
+	old, err := db.Cases("confirmed", "italy", now.AddDate(0, 0, -10))
+	require.NoError(t, err, "error")
+	estimated, err := db.ActiveCases("italy", now)
+	require.NoError(t, err, "error")
+	assert.Equal(t, confirmed-old, estimated, "estimated active cases")
+	assert.True(t, estimated < reported, "estimated less than reported")
+
+	db.Estimate("italy", 100)
+	estimated, err = db.ActiveCases("italy", now)
+	require.NoError(t, err, "error")
+	assert.Equal(t, reported, estimated, "reported closed cases before first date")
+
+	db.Estimate("China / Hubei", 10)
+	assert.True(t, db.Estimated("china/hubei"), "province estimated")
+
+	db.Estimate("italy", 0)
+	assert.False(t, db.Estimated("italy"), "italy estimated")
+}
+
+func TestGeo(t *testing.T) {
+	defer setup().Teardown()
+
+	db := database.New(env.ServerURL(), env.TmpDir(), time.Second)
+	db.Set("confirmed", "/confirmed.csv", database.Total)
+
+	places, err := db.Places()
+	require.NoError(t, err, "error")
+	assert.Len(t, places, 468, "places")
+
+	near, err := db.Near(41.9, 12.5, 500) // Rome
+	require.NoError(t, err, "error")
+	require.NotEmpty(t, near, "near")
+	assert.Equal(t, "Holy See", near[0].Location(), "nearest")
+	locations := []string{}
+	for _, p := range near {
+		assert.True(t, p.Distance(41.9, 12.5) <= 500, p.Location())
+		locations = append(locations, p.Location())
+	}
+	assert.Contains(t, locations, "Italy", "near locations")
+	assert.NotContains(t, locations, "Spain", "near locations")
+
+	p, err := db.Closest(30.6, 114.3) // Wuhan
+	require.NoError(t, err, "error")
+	assert.Equal(t, "China/Hubei", p.Location(), "closest")
+	assert.InDelta(t, 195, p.Distance(30.6, 114.3), 5, "distance")
+
+	cases, err := db.Cases("confirmed", p.Location(), date(2020, time.January, 22))
+	require.NoError(t, err, "error")
+	assert.Equal(t, 444, cases, "province cases")
+}
+
+func TestDerive(t *testing.T) {
+	defer setup().Teardown()
+
+	db := database.New(env.ServerURL(), env.TmpDir(), time.Second)
+	db.Set("confirmed", "/confirmed.csv", database.Total)
+	db.Set("recovered", "/recovered.csv", database.Subtract)
+	db.Set("dead", "/deaths.csv", database.Subtract)
+
+	for expr, msg := range map[string]string{
+		"":                     "database: empty expression",
+		"confirmed -":          "database: missing name in expression `confirmed -`",
+		"confirmed + - dead":   "database: missing name in expression `confirmed + - dead`",
+		"+ - dead":             "database: missing name in expression `+ - dead`",
+		"confirmed - infected": "database: unknown series `infected` in expression `confirmed - infected`",
+		"confirmed - open":     "database: series `open` can't be derived from itself",
+	} {
+		assert.EqualError(t, db.Derive("open", expr), msg, expr)
+	}
+	assert.EqualError(t, db.Derive("dead", "confirmed"), "database: name `dead` already set as resource", "resource name")
+
+	require.NoError(t, db.Derive("closed", "recovered + dead"), "derive closed")
+	require.NoError(t, db.Derive("active", " - closed + confirmed"), "derive active")
+	cases, err := db.Cases("active", "italy", date(2020, time.March, 3))
+	require.NoError(t, err, "error")
+	assert.Equal(t, 2263, cases, "active cases")
+
+	require.NoError(t, db.Derive("a", "confirmed"), "derive a")
+	require.NoError(t, db.Derive("b", "a"), "derive b")
+	assert.EqualError(t, db.Derive("a", "b"), "database: series `a` can't be derived from itself", "indirect cycle")
+	require.NoError(t, db.Derive("a", "dead"), "redefine a")
+}
+
+func TestLocal(t *testing.T) {
+	defer setup().Teardown()
+
+	db := database.NewLocal(env.fix.dir)
+	db.Set("confirmed", "", database.Total)
+	latest, err := db.Latest()
+	require.NoError(t, err, "error")
+	assert.Equal(t, date(2020, time.March, 19), latest, "time")
+
+	db = database.NewLocal(env.TmpDir())
+	db.Set("confirmed", "", database.Total)
+	_, err = db.Latest()
+	missing := filepath.Join(env.TmpDir(), "confirmed.csv")
+	assert.EqualError(t, err, "database: missing "+missing, "missing file")
+	_, err = os.Stat(missing)
+	assert.True(t, os.IsNotExist(err), "missing file created")
+}
+
 func handler(w http.ResponseWriter, r *http.Request) {
 	path := strings.ReplaceAll(r.URL.EscapedPath(), "/", "")
 	b, err := env.Fixture(path)
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expression is a sum of named series, each one added or subtracted (e.g. "confirmed - recovered - dead").
+type expression []term
+
+type term struct {
+	sign int
+	name EndpointName
+}
+
+func parseExpression(s string) (expression, error) {
+	var (
+		e      expression
+		sign   = 1
+		signed bool
+		name   strings.Builder
+	)
+	push := func(next int) error {
+		n := strings.TrimSpace(name.String())
+		name.Reset()
+		if n == "" {
+			if len(e) == 0 && !signed {
+				// leading sign
+				sign, signed = next, true
+				return nil
+			}
+			return fmt.Errorf("missing name in expression `%s`", s)
+		}
+		e = append(e, term{sign, EndpointName(n)})
+		sign = next
+		return nil
+	}
+	for _, c := range s {
+		switch c {
+		case '+':
+			if err := push(1); err != nil {
+				return nil, err
+			}
+		case '-':
+			if err := push(-1); err != nil {
+				return nil, err
+			}
+		default:
+			name.WriteRune(c)
+		}
+	}
+	if err := push(1); err != nil {
+		return nil, err
+	}
+	if len(e) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return e, nil
+}
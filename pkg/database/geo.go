@@ -0,0 +1,114 @@
+package database
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsidew/covid/internal/errors"
+)
+
+const earthRadius = 6371.0088 // mean radius in km
+
+// Place of the cases in the resources, i.e. a country or one of its provinces, with its coordinates.
+type Place struct {
+	Country, Province string
+	Lat, Long         float64
+}
+
+// Location of the place, to select its cases (e.g. with DB.ActiveCases):
+// either COUNTRY or COUNTRY/PROVINCE.
+func (p Place) Location() string {
+	if p.Province == "" {
+		return p.Country
+	}
+	return p.Country + "/" + p.Province
+}
+
+// Distance in km from the coordinates, along the great circle.
+func (p Place) Distance(lat, long float64) float64 {
+	lat1, lat2 := radians(p.Lat), radians(lat)
+	dlat, dlong := lat2-lat1, radians(long-p.Long)
+	a := math.Pow(math.Sin(dlat/2), 2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlong/2), 2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Places listed in the Total resource.
+func (db *DB) Places() ([]Place, error) {
+	m, err := db.totalMatrix()
+	if err != nil {
+		return nil, err
+	}
+	return m.Places(), nil
+}
+
+// Near places, within radius km from the coordinates, sorted by distance.
+func (db *DB) Near(lat, long, radius float64) ([]Place, error) {
+	places, err := db.Places()
+	if err != nil {
+		return nil, err
+	}
+	near := []Place{}
+	for _, p := range places {
+		if p.Distance(lat, long) <= radius {
+			near = append(near, p)
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		return near[i].Distance(lat, long) < near[j].Distance(lat, long)
+	})
+	return near, nil
+}
+
+// Closest place to the coordinates.
+func (db *DB) Closest(lat, long float64) (Place, error) {
+	near, err := db.Near(lat, long, math.Inf(1))
+	if err != nil {
+		return Place{}, err
+	}
+	if len(near) == 0 {
+		return Place{}, errors.F("no places listed")
+	}
+	return near[0], nil
+}
+
+func (m matrix) Places() []Place {
+	places := []Place{}
+	for _, row := range m[1:] {
+		lat, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil {
+			continue
+		}
+		long, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 64)
+		if err != nil {
+			continue
+		}
+		places = append(places, Place{
+			Country:  strings.TrimSpace(row[1]),
+			Province: strings.TrimSpace(row[0]),
+			Lat:      lat, Long: long,
+		})
+	}
+	return places
+}
+
+// match tells if a row is selected by location: either empty for all rows, COUNTRY or COUNTRY/PROVINCE.
+func match(row []string, location string) bool {
+	if location == "" {
+		return true
+	}
+	country, province := location, ""
+	if i := strings.Index(location, "/"); i >= 0 {
+		country, province = location[:i], location[i+1:]
+	}
+	if !strings.EqualFold(strings.TrimSpace(country), strings.TrimSpace(row[1])) {
+		return false
+	}
+	return province == "" || strings.EqualFold(strings.TrimSpace(province), strings.TrimSpace(row[0]))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
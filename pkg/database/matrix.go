@@ -3,6 +3,7 @@ package database
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sort"
@@ -11,7 +12,10 @@ import (
 	"time"
 )
 
-const formatFromCSV = "1/2/06" // month/day/year
+const (
+	formatFromCSV = "1/2/06" // month/day/year
+	coldatefrom   = 4
+)
 
 type matrix [][]string
 
@@ -38,27 +42,18 @@ func newMatrix(r io.Reader) (matrix, error) {
 }
 
 func (m matrix) Cases(country string, t time.Time) (int, error) {
-
-	const coldatefrom = 4
-
-	// find column index
-	var colix int
-	for i := range m[0][coldatefrom:] {
-		u, err := time.Parse(formatFromCSV, m[0][i+coldatefrom])
-		if err != nil {
-			return 0, err
-		}
-		if !u.Equal(t) {
-			continue
-		}
-		colix = i + coldatefrom
-		break
+	colix, err := m.column(t)
+	if err != nil || colix < 0 {
+		return 0, err
 	}
 
 	// aggregate count by country
 	var sum int
 	for _, row := range m[1:] {
-		if country != "" && !strings.EqualFold(country, strings.TrimSpace(row[1])) {
+		if !match(row, country) {
+			continue
+		}
+		if colix >= len(row) {
 			continue
 		}
 		val := strings.TrimSpace(row[colix])
@@ -80,6 +75,38 @@ func (m matrix) Cases(country string, t time.Time) (int, error) {
 	return sum, nil
 }
 
+// column index of the time t, or -1 if t is before the first date (when there were no cases).
+func (m matrix) column(t time.Time) (int, error) {
+	// columns are usually consecutive days
+	if first, err := time.Parse(formatFromCSV, m[0][coldatefrom]); err == nil && !t.Before(first) {
+		i := coldatefrom + int(t.Sub(first).Hours()/24)
+		if i < len(m[0]) && m[0][i] == t.Format(formatFromCSV) {
+			return i, nil
+		}
+	}
+	for i := range m[0][coldatefrom:] {
+		u, err := time.Parse(formatFromCSV, m[0][i+coldatefrom])
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 && t.Before(u) {
+			return -1, nil
+		}
+		if u.Equal(t) {
+			return i + coldatefrom, nil
+		}
+	}
+	return 0, fmt.Errorf("no data for %s", t.Format("2006-01-02"))
+}
+
+func (m matrix) First() (time.Time, error) {
+	t, err := time.Parse(formatFromCSV, m[0][coldatefrom])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
 func (m matrix) Latest() (time.Time, error) {
 	t, err := time.Parse(formatFromCSV, m[0][len(m[0])-1])
 	if err != nil {
@@ -18,14 +18,16 @@ type resource struct {
 	name, url, filepath string
 
 	expire time.Duration
+	local  bool
 	mx     matrix
 }
 
-func (r resources) Set(db, name, url string, expire time.Duration) {
+func (r resources) Set(db, name, url string, expire time.Duration, local bool) {
 	r[name] = resource{
 		name: name, url: url,
 		filepath: filepath.Join(db, name+filext),
 		expire:   expire,
+		local:    local,
 	}
 }
 
@@ -42,6 +44,12 @@ func (r resources) Get(name string) (matrix, error) {
 	return m, nil
 }
 
+// Loaded matrix of a resource, if already in memory.
+func (r resources) Loaded(name string) (matrix, bool) {
+	res, ok := r[name]
+	return res.mx, ok && res.mx != nil
+}
+
 func (r *resource) Get() (matrix, error) {
 	if r.mx != nil {
 		return r.mx, nil
@@ -59,6 +67,9 @@ func (r resource) Name() string {
 }
 
 func (r resource) open() (matrix, error) {
+	if r.local {
+		return r.read()
+	}
 	f, err := os.Open(r.filepath)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -96,6 +107,18 @@ parse:
 	return newMatrix(f)
 }
 
+// read a local resource, without ever writing its file.
+func (r resource) read() (matrix, error) {
+	f, err := os.Open(r.filepath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("missing %s", r.filepath)
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return newMatrix(f)
+}
+
 func (r resource) update(w io.Writer) error {
 
 	var body io.Reader
@@ -0,0 +1,129 @@
+/*
+Package events provides the interventions on the spread of the epidemic, like lockdowns, school closures and reopenings,
+to annotate the status and to measure their impact.
+
+The events are read from a CSV file with header "country,date,label" and dates with format 2006-01-02,
+where a country can also be a province as COUNTRY/PROVINCE (e.g. "China/Hubei"), like the locations of the database,
+and "World" is the whole world.
+*/
+package events
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/internal/location"
+)
+
+const (
+	// File name of the events, in the profile or data directory.
+	File = "events.csv"
+
+	// Layout of the dates of the events.
+	Layout = "2006-01-02"
+)
+
+var errs = errors.Prefixed("events")
+
+// Event of a country.
+type Event struct {
+	Country string
+	Date    time.Time
+	Label   string
+}
+
+// Events sorted by date.
+type Events []Event
+
+// Load the events from a CSV file: if the file doesn't exist, there are no events.
+func Load(path string) (Events, error) {
+	f, err := location.Open(path)
+	if err != nil {
+		return nil, errs.W(err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read the events from CSV data.
+func Read(r io.Reader) (Events, error) {
+	rows, err := location.Rows(r, "country", "date", "label")
+	if err != nil {
+		return nil, errs.W(err)
+	}
+
+	evs := make(Events, 0, len(rows))
+	for i, row := range rows {
+		t, err := time.Parse(Layout, strings.TrimSpace(row[1]))
+		if err != nil {
+			return nil, errs.F("invalid date `%s` at line %d", row[1], i+2)
+		}
+		e := Event{
+			Country: strings.TrimSpace(row[0]),
+			Date:    t,
+			Label:   strings.TrimSpace(row[2]),
+		}
+		if e.Label == "" {
+			return nil, errs.F("missing label at line %d", i+2)
+		}
+		evs = append(evs, e)
+	}
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].Date.Before(evs[j].Date) })
+	return evs, nil
+}
+
+// Of a location (COUNTRY or COUNTRY/PROVINCE): the empty location is the world.
+func (evs Events) Of(loc string) Events {
+	list := Events{}
+	for _, e := range evs {
+		if location.Key(e.Country) == location.Key(loc) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Until a time, included.
+func (evs Events) Until(t time.Time) Events {
+	list := Events{}
+	for _, e := range evs {
+		if !e.Date.After(t) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// On a day.
+func (evs Events) On(t time.Time) Events {
+	list := Events{}
+	for _, e := range evs {
+		if e.Date.Equal(t) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Labelled with a label, case insensitive.
+func (evs Events) Labelled(label string) Events {
+	list := Events{}
+	for _, e := range evs {
+		if strings.EqualFold(e.Label, strings.TrimSpace(label)) {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Labels of the events.
+func (evs Events) Labels() []string {
+	list := make([]string, len(evs))
+	for i, e := range evs {
+		list[i] = e.Label
+	}
+	return list
+}
@@ -0,0 +1,47 @@
+package events_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/events"
+)
+
+const data = `country,date,label
+Italy,2020-03-09,lockdown
+China/Hubei,2020-01-23,lockdown
+Italy,2020-03-05,school closure
+World,2020-03-11,pandemic
+`
+
+func Test(t *testing.T) {
+	evs, err := events.Read(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, evs, 4)
+	assert.Equal(t, "China/Hubei", evs[0].Country, "sorted by date")
+
+	italy := evs.Of(" ITALY ")
+	assert.Equal(t, []string{"school closure", "lockdown"}, italy.Labels())
+	assert.Equal(t, []string{"lockdown"}, evs.Of("china / hubei").Labels(), "province")
+	assert.Equal(t, []string{"pandemic"}, evs.Of("").Labels(), "world")
+	assert.Empty(t, evs.Of("China"), "country of a province")
+
+	day := time.Date(2020, 3, 5, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"school closure"}, italy.Until(day).Labels())
+	assert.Equal(t, []string{"school closure"}, italy.On(day).Labels())
+	assert.Equal(t, []string{"lockdown"}, italy.Labelled("Lockdown").Labels())
+
+	_, err = events.Read(strings.NewReader("country,label\nItaly,lockdown\n"))
+	assert.EqualError(t, err, "events: header should be country,date,label")
+	_, err = events.Read(strings.NewReader("country,date,label\nItaly,9 Mar 2020,lockdown\n"))
+	assert.EqualError(t, err, "events: invalid date `9 Mar 2020` at line 2")
+
+	evs, err = events.Load(filepath.Join(t.Name(), "phantom.csv"))
+	assert.NoError(t, err, "missing file")
+	assert.Empty(t, evs, "missing file")
+}
@@ -0,0 +1,203 @@
+/*
+Package model provides compartmental models of epidemics, SIR and SEIR, solved numerically and fitted to the cases.
+
+The population is split into susceptible (S), exposed (E, infected but not yet infectious, only for SEIR),
+infectious (I) and removed (R, either recovered or dead) people:
+unlike the exponential projections of package calc, the spread slows down as susceptible people get fewer.
+The reported active cases are fitted as the infectious people:
+people that are never reported as cases are not considered.
+*/
+package model
+
+import (
+	"math"
+
+	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/pkg/calc"
+)
+
+// Kinds of model.
+const (
+	SIR  Kind = "sir"
+	SEIR Kind = "seir"
+)
+
+// Steps of the solution per day.
+const steps = 4
+
+var errs = errors.Prefixed("model")
+
+// Kind of model.
+type Kind string
+
+// Params of a model.
+type Params struct {
+	Kind       Kind
+	Population float64
+
+	// Beta is the daily rate of infection, per infectious person.
+	Beta float64
+
+	// Incubation is the mean number of days exposed, before being infectious (only for SEIR).
+	Incubation float64
+
+	// Infectious is the mean number of days infectious, before being removed.
+	Infectious float64
+}
+
+// State of the compartments of the population.
+type State struct {
+	S, E, I, R float64
+}
+
+// Fit of a model to a series of active cases.
+type Fit struct {
+	Params
+
+	// Start is the state on the first day of the series.
+	Start State
+
+	// Days of the series.
+	Days int
+
+	// RMSE is the root mean square error of the logarithms of the active cases.
+	RMSE float64
+}
+
+// Outcome of an epidemic.
+type Outcome struct {
+	// PeakDay is the day with the most infectious people, from the start.
+	PeakDay int
+
+	// PeakCases is the number of infectious people on PeakDay.
+	PeakCases float64
+
+	// AttackRate is the fraction of the population infected by the end of the epidemic.
+	AttackRate float64
+}
+
+// R0 is the basic reproduction number of the model.
+func (p Params) R0() float64 {
+	return p.Beta * p.Infectious
+}
+
+/*
+Solve the model from a start state for a number of days,
+with the Runge-Kutta method of 4th order:
+the result has one state per day, the start included.
+*/
+func (p Params) Solve(start State, days int) []State {
+	states := make([]State, days+1)
+	states[0] = start
+	h := 1.0 / steps
+	s := start
+	for d := 1; d <= days; d++ {
+		for i := 0; i < steps; i++ {
+			k1 := p.derivative(s)
+			k2 := p.derivative(s.add(k1, h/2))
+			k3 := p.derivative(s.add(k2, h/2))
+			k4 := p.derivative(s.add(k3, h))
+			s = s.add(k1, h/6).add(k2, h/3).add(k3, h/3).add(k4, h/6)
+		}
+		states[d] = s
+	}
+	return states
+}
+
+// Outcome of the epidemic from a start state, within a maximum number of days.
+func (p Params) Outcome(start State, days int) Outcome {
+	states := p.Solve(start, days)
+	o := Outcome{}
+	for d, s := range states {
+		if s.I > o.PeakCases {
+			o.PeakDay, o.PeakCases = d, s.I
+		}
+	}
+	o.AttackRate = 1 - states[len(states)-1].S/p.Population
+	return o
+}
+
+/*
+FitTo fits the daily rate of infection (Beta) of the model to a series of active cases, one per day,
+starting from the active and removed (closed) cases of the first day:
+the other parameters are kept.
+*/
+func (p Params) FitTo(active []float64, removed float64) (Fit, error) {
+	switch {
+	case p.Kind != SIR && p.Kind != SEIR:
+		return Fit{}, errs.F("unknown model `%s`", p.Kind)
+	case p.Population <= 0:
+		return Fit{}, errs.F("unknown population")
+	case p.Infectious <= 0 || (p.Kind == SEIR && p.Incubation <= 0):
+		return Fit{}, errs.F("the days of incubation and infection should be positive")
+	case len(active) < 3 || active[0] <= 0:
+		return Fit{}, calc.ErrInsufficientData
+	case active[0]+removed > p.Population:
+		return Fit{}, errs.F("more cases than population")
+	}
+
+	start := State{I: active[0], R: removed}
+	if p.Kind == SEIR {
+		// exposed people growing like the infectious ones
+		g := 0.0
+		if f, err := calc.RateFit(active, nil); err == nil {
+			g = math.Log(f.Rate)
+		}
+		start.E = math.Max(0, active[0]*(g+1/p.Infectious)*p.Incubation)
+	}
+	start.S = math.Max(0, p.Population-start.E-start.I-start.R)
+
+	// the start day always matches and isn't compared: the error is infinite without any other day to compare
+	rmse := func(beta float64) float64 {
+		q := p
+		q.Beta = beta
+		var sse float64
+		n := 0
+		for d, s := range q.Solve(start, len(active)-1) {
+			if d == 0 || active[d] <= 0 || s.I <= 0 {
+				continue
+			}
+			e := math.Log(s.I) - math.Log(active[d])
+			sse += e * e
+			n++
+		}
+		if n == 0 {
+			return math.Inf(1)
+		}
+		return math.Sqrt(sse / float64(n))
+	}
+
+	// golden section search of the logarithm of beta
+	phi := (math.Sqrt(5) - 1) / 2
+	a, b := math.Log(0.001), math.Log(10)
+	for b-a > 1e-6 {
+		c, d := b-phi*(b-a), a+phi*(b-a)
+		if rmse(math.Exp(c)) < rmse(math.Exp(d)) {
+			b = d
+		} else {
+			a = c
+		}
+	}
+	p.Beta = math.Exp((a + b) / 2)
+
+	e := rmse(p.Beta)
+	if math.IsInf(e, 1) {
+		return Fit{}, calc.ErrInsufficientData
+	}
+	return Fit{Params: p, Start: start, Days: len(active), RMSE: e}, nil
+}
+
+func (p Params) derivative(s State) State {
+	infections := p.Beta * s.S * s.I / p.Population
+	removals := s.I / p.Infectious
+	if p.Kind == SIR {
+		return State{S: -infections, I: infections - removals, R: removals}
+	}
+	onsets := s.E / p.Incubation
+	return State{S: -infections, E: infections - onsets, I: onsets - removals, R: removals}
+}
+
+// add the derivative d of the state for a step h.
+func (s State) add(d State, h float64) State {
+	return State{s.S + d.S*h, s.E + d.E*h, s.I + d.I*h, s.R + d.R*h}
+}
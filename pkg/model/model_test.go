@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/model"
+)
+
+func Test(t *testing.T) {
+	p := model.Params{Kind: model.SIR, Population: 1e6, Beta: 0.3, Infectious: 10}
+	assert.InDelta(t, 3, p.R0(), 1e-12, "R0")
+
+	states := p.Solve(model.State{S: 1e6 - 10, I: 10}, 100)
+	assert.Len(t, states, 101, "states")
+	for _, s := range states {
+		assert.InDelta(t, 1e6, s.S+s.E+s.I+s.R, 1e-6, "population")
+	}
+
+	// final size equation of SIR: 1 - A = exp(-R0 A)
+	o := p.Outcome(model.State{S: 1e6 - 1, I: 1}, 1000)
+	assert.InDelta(t, 1-o.AttackRate, math.Exp(-p.R0()*o.AttackRate), 1e-4, "attack rate")
+	assert.True(t, o.PeakDay > 0 && o.PeakCases > 0, "peak")
+}
+
+func TestFit(t *testing.T) {
+	for _, kind := range []model.Kind{model.SIR, model.SEIR} {
+		p := model.Params{Kind: kind, Population: 1e7, Beta: 0.5, Infectious: 7, Incubation: 5}
+		states := p.Solve(model.State{S: 1e7 - 1100, E: 1000, I: 100}, 60)
+		active := []float64{}
+		for _, s := range states[40:] {
+			active = append(active, s.I)
+		}
+
+		q := p
+		q.Beta = 0
+		f, err := q.FitTo(active, states[40].R)
+		require.NoError(t, err, "%s", kind)
+		assert.InDelta(t, p.Beta, f.Beta, 0.05, "%s beta", kind)
+		assert.True(t, f.RMSE < 0.05, "%s error", kind)
+		assert.Equal(t, 21, f.Days, "%s days", kind)
+	}
+
+	p := model.Params{Kind: model.SIR, Infectious: 7}
+	_, err := p.FitTo([]float64{1, 2, 3}, 0)
+	assert.EqualError(t, err, "model: unknown population")
+	p.Population = 1e6
+	_, err = p.FitTo([]float64{0, 2}, 0)
+	assert.Equal(t, calc.ErrInsufficientData, err)
+	_, err = p.FitTo([]float64{5, 0, 0}, 0)
+	assert.Equal(t, calc.ErrInsufficientData, err, "no day to compare")
+}
@@ -0,0 +1,160 @@
+package registry
+
+// defaults is the registry of the countries in the data sources, with their population as of 2020.
+const defaults = `country,population,continent
+Afghanistan,38928346,Asia
+Albania,2877797,Europe
+Algeria,43851044,Africa
+Andorra,77265,Europe
+Antigua and Barbuda,97929,North America
+Argentina,45195774,South America
+Armenia,2963243,Asia
+Australia,25499884,Oceania
+Austria,9006398,Europe
+Azerbaijan,10139177,Asia
+"Bahamas, The",393244,North America
+Bahrain,1701575,Asia
+Bangladesh,164689383,Asia
+Barbados,287375,North America
+Belarus,9449323,Europe
+Belgium,11589623,Europe
+Benin,12123200,Africa
+Bhutan,771608,Asia
+Bolivia,11673021,South America
+Bosnia and Herzegovina,3280819,Europe
+Brazil,212559417,South America
+Brunei,437479,Asia
+Bulgaria,6948445,Europe
+Burkina Faso,20903273,Africa
+Cambodia,16718965,Asia
+Cameroon,26545863,Africa
+Canada,37742154,North America
+Central African Republic,4829767,Africa
+Chad,16425864,Africa
+Chile,19116201,South America
+China,1439323776,Asia
+China/Hubei,58500000,
+Colombia,50882891,South America
+Congo (Brazzaville),5518087,Africa
+Congo (Kinshasa),89561403,Africa
+Costa Rica,5094118,North America
+Cote d'Ivoire,26378274,Africa
+Croatia,4105267,Europe
+Cuba,11326616,North America
+Cyprus,1207359,Europe
+Czechia,10708981,Europe
+Denmark,5792202,Europe
+Djibouti,988000,Africa
+Dominican Republic,10847910,North America
+Ecuador,17643054,South America
+Egypt,102334404,Africa
+El Salvador,6486205,North America
+Equatorial Guinea,1402985,Africa
+Estonia,1326535,Europe
+Eswatini,1160164,Africa
+Ethiopia,114963588,Africa
+Fiji,896445,Oceania
+Finland,5540720,Europe
+France,65273511,Europe
+Gabon,2225734,Africa
+"Gambia, The",2416668,Africa
+Georgia,3989167,Asia
+Germany,83783942,Europe
+Ghana,31072940,Africa
+Greece,10423054,Europe
+Guatemala,17915568,North America
+Guinea,13132795,Africa
+Guyana,786552,South America
+Holy See,801,Europe
+Honduras,9904607,North America
+Hungary,9660351,Europe
+Iceland,341243,Europe
+India,1380004385,Asia
+Indonesia,273523615,Asia
+Iran,83992949,Asia
+Iraq,40222493,Asia
+Ireland,4937786,Europe
+Israel,8655535,Asia
+Italy,60461826,Europe
+Jamaica,2961167,North America
+Japan,126476461,Asia
+Jordan,10203134,Asia
+Kazakhstan,18776707,Asia
+Kenya,53771296,Africa
+"Korea, South",51269185,Asia
+Kosovo,1810366,Europe
+Kuwait,4270571,Asia
+Kyrgyzstan,6524195,Asia
+Latvia,1886198,Europe
+Lebanon,6825445,Asia
+Liberia,5057681,Africa
+Liechtenstein,38128,Europe
+Lithuania,2722289,Europe
+Luxembourg,625978,Europe
+Malaysia,32365999,Asia
+Maldives,540544,Asia
+Malta,441543,Europe
+Martinique,375265,North America
+Mauritania,4649658,Africa
+Mauritius,1271768,Africa
+Mexico,128932753,North America
+Moldova,4033963,Europe
+Monaco,39242,Europe
+Mongolia,3278290,Asia
+Montenegro,628066,Europe
+Morocco,36910560,Africa
+Namibia,2540905,Africa
+Nepal,29136808,Asia
+Netherlands,17134872,Europe
+New Zealand,4822233,Oceania
+Nicaragua,6624554,North America
+Nigeria,206139589,Africa
+North Macedonia,2083374,Europe
+Norway,5421241,Europe
+Oman,5106626,Asia
+Pakistan,220892340,Asia
+Panama,4314767,North America
+Paraguay,7132538,South America
+Peru,32971854,South America
+Philippines,109581078,Asia
+Poland,37846611,Europe
+Portugal,10196709,Europe
+Qatar,2881053,Asia
+Romania,19237691,Europe
+Russia,145934462,Europe
+Rwanda,12952218,Africa
+Saint Lucia,183627,North America
+Saint Vincent and the Grenadines,110940,North America
+San Marino,33931,Europe
+Saudi Arabia,34813871,Asia
+Senegal,16743927,Africa
+Serbia,8737371,Europe
+Seychelles,98347,Africa
+Singapore,5850342,Asia
+Slovakia,5459642,Europe
+Slovenia,2078938,Europe
+Somalia,15893222,Africa
+South Africa,59308690,Africa
+Spain,46754778,Europe
+Sri Lanka,21413249,Asia
+Sudan,43849260,Africa
+Suriname,586632,South America
+Sweden,10099265,Europe
+Switzerland,8654622,Europe
+Taiwan*,23816775,Asia
+Tanzania,59734218,Africa
+Thailand,69799978,Asia
+Togo,8278724,Africa
+Trinidad and Tobago,1399488,North America
+Tunisia,11818619,Africa
+Turkey,84339067,Asia
+US,331002651,North America
+Ukraine,43733762,Europe
+United Arab Emirates,9890402,Asia
+United Kingdom,67886011,Europe
+Uruguay,3473730,South America
+Uzbekistan,33469203,Asia
+Venezuela,28435940,South America
+Vietnam,97338579,Asia
+Zambia,18383955,Africa
+`
@@ -0,0 +1,134 @@
+/*
+Package registry provides information about countries and provinces that the data sources don't have,
+like their population and continent.
+
+The registry is read from a CSV file with header "country,population,continent",
+where a country can also be a province as COUNTRY/PROVINCE (e.g. "China/Hubei"), like the locations of the database.
+A default registry of the countries of the data sources is bundled, and the rows of the file override it.
+*/
+package registry
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/internal/location"
+)
+
+// File name of the registry, in the profile or data directory.
+const File = "countries.csv"
+
+var errs = errors.Prefixed("registry")
+
+// Country information.
+type Country struct {
+	Name       string
+	Population int
+	Continent  string
+}
+
+// Registry of countries and provinces, by location.
+type Registry map[string]Country
+
+// Default registry, with the population as of 2020 of the countries of the data sources.
+func Default() Registry {
+	reg, err := Read(strings.NewReader(defaults))
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}
+
+// Load the registry from a CSV file, over the default one: if the file doesn't exist, the registry is the default.
+func Load(path string) (Registry, error) {
+	reg := Default()
+	f, err := location.Open(path)
+	if err != nil {
+		return nil, errs.W(err)
+	}
+	defer f.Close()
+	file, err := Read(f)
+	if err != nil {
+		return nil, err
+	}
+	for k, c := range file {
+		reg[k] = c
+	}
+	return reg, nil
+}
+
+// Read the registry from CSV data.
+func Read(r io.Reader) (Registry, error) {
+	rows, err := location.Rows(r, "country", "population", "continent")
+	if err != nil {
+		return nil, errs.W(err)
+	}
+
+	reg := make(Registry, len(rows))
+	for i, row := range rows {
+		p, err := strconv.Atoi(strings.TrimSpace(row[1]))
+		if err != nil || p < 0 {
+			return nil, errs.F("invalid population `%s` at line %d", row[1], i+2)
+		}
+		c := Country{
+			Name:       strings.TrimSpace(row[0]),
+			Population: p,
+			Continent:  strings.TrimSpace(row[2]),
+		}
+		reg[location.Key(c.Name)] = c
+	}
+	return reg, nil
+}
+
+// Get the information of a location (COUNTRY or COUNTRY/PROVINCE).
+// The empty location (or "world") is the world, with the population of all countries.
+func (reg Registry) Get(loc string) (Country, bool) {
+	k := location.Key(loc)
+	if k == "" {
+		w := Country{Name: "World"}
+		for _, c := range reg {
+			if !strings.Contains(c.Name, "/") {
+				w.Population += c.Population
+			}
+		}
+		return w, w.Population > 0
+	}
+	c, ok := reg[k]
+	return c, ok
+}
+
+// Population of a location, or 0 if unknown.
+func (reg Registry) Population(loc string) int {
+	c, _ := reg.Get(loc)
+	return c.Population
+}
+
+// Continent of a location, or the continent of its country for provinces without one.
+func (reg Registry) Continent(loc string) string {
+	if c, ok := reg.Get(loc); ok && c.Continent != "" {
+		return c.Continent
+	}
+	if i := strings.Index(loc, "/"); i > 0 {
+		return reg.Continent(loc[:i])
+	}
+	return ""
+}
+
+// Continents in the registry, sorted by name.
+func (reg Registry) Continents() []string {
+	set := map[string]bool{}
+	for _, c := range reg {
+		if c.Continent != "" {
+			set[c.Continent] = true
+		}
+	}
+	list := make([]string, 0, len(set))
+	for c := range set {
+		list = append(list, c)
+	}
+	sort.Strings(list)
+	return list
+}
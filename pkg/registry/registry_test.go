@@ -0,0 +1,66 @@
+package registry_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/registry"
+)
+
+const data = `country,population,continent
+Italy,60461826,Europe
+Spain,46754778,Europe
+China,1439323776,Asia
+China/Hubei,58500000,
+`
+
+func Test(t *testing.T) {
+	reg, err := registry.Read(strings.NewReader(data))
+	require.NoError(t, err)
+
+	c, ok := reg.Get(" italy ")
+	assert.True(t, ok, "country")
+	assert.Equal(t, registry.Country{Name: "Italy", Population: 60461826, Continent: "Europe"}, c)
+	assert.Equal(t, 58500000, reg.Population("china / HUBEI"), "province")
+	assert.Equal(t, 60461826+46754778+1439323776, reg.Population(""), "world")
+	assert.Equal(t, reg.Population(""), reg.Population(" World "), "world by name")
+	assert.Equal(t, 0, reg.Population("Atlantis"), "unknown country")
+
+	assert.Equal(t, "Asia", reg.Continent("China/Hubei"), "continent of the country of a province")
+	assert.Equal(t, []string{"Asia", "Europe"}, reg.Continents())
+
+	_, err = registry.Read(strings.NewReader("country,continent\nItaly,Europe\n"))
+	assert.EqualError(t, err, "registry: header should be country,population,continent")
+	_, err = registry.Read(strings.NewReader("country,population,continent\nItaly,many,Europe\n"))
+	assert.EqualError(t, err, "registry: invalid population `many` at line 2")
+
+	reg, err = registry.Load(filepath.Join(t.Name(), "phantom.csv"))
+	assert.NoError(t, err, "missing file")
+	assert.Equal(t, registry.Default(), reg, "missing file")
+}
+
+func TestLoad(t *testing.T) {
+	def := registry.Default()
+	assert.Equal(t, 60461826, def.Population("Italy"), "default country")
+	assert.Equal(t, "Europe", def.Continent("Italy"), "default continent")
+	assert.Equal(t, "Asia", def.Continent("China/Hubei"), "default province")
+
+	f, err := ioutil.TempFile("", "countries-*.csv")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("country,population,continent\nItaly,60000000,Europe\nAtlantis,1000,Ocean\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reg, err := registry.Load(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, 60000000, reg.Population("italy"), "overridden country")
+	assert.Equal(t, 1000, reg.Population("atlantis"), "added country")
+	assert.Equal(t, def.Population("Spain"), reg.Population("spain"), "default country")
+}
@@ -0,0 +1,214 @@
+/*
+Package synth generates synthetic datasets of cases, in the wide format of the data sources
+(one row per country, one column per day), from known spread rates and dim factors.
+
+The active cases of a country follow the forecast with moving rate (see calc.Forecast3D),
+while a fraction of them is closed every day, either recovered or dead.
+Noise and reporting artifacts can be added to the reported daily cases,
+to check that the computed rates recover the generated ones.
+*/
+package synth
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/pkg/calc"
+)
+
+const formatToCSV = "1/2/06" // month/day/year
+
+var errs = errors.Prefixed("synth")
+
+// Country parameters to generate its cases.
+type Country struct {
+	Name, Province string
+	Lat, Long      float64
+
+	// Cases active on the first day.
+	Cases float64
+
+	// Rate of spread of the active cases on the first day.
+	Rate float64
+
+	// DimFactor is the daily rate of Rate (1 for a constant spread rate).
+	DimFactor float64
+
+	// Closing is the fraction of the active cases closed daily (recovered or dead),
+	// as long as the active cases can follow their rates (new cases can't be negative).
+	Closing float64
+
+	// Fatality is the fraction of the closed cases that are dead.
+	Fatality float64
+}
+
+// Config of the generated dataset.
+type Config struct {
+	Start     time.Time
+	Days      int
+	Countries []Country
+
+	// Noise is the standard deviation of the log-normal noise on the reported daily cases.
+	Noise float64
+
+	// Weekend is the fraction of the daily cases of Saturdays and Sundays reported on the next Monday.
+	Weekend float64
+
+	// RecoveredDays is the number of days after which recovered cases aren't reported anymore (0 for never).
+	RecoveredDays int
+
+	// Seed of the random noise.
+	Seed int64
+}
+
+// Dataset of reported cumulative cases, indexed by country and day.
+type Dataset struct {
+	Start     time.Time
+	Countries []Country
+
+	Confirmed, Recovered, Deaths [][]int
+}
+
+// Generate a dataset.
+func Generate(cfg Config) (*Dataset, error) {
+	if cfg.Days < 1 {
+		return nil, errs.F("days should be at least 1")
+	}
+	if cfg.Noise < 0 {
+		return nil, errs.F("noise should be positive")
+	}
+	if cfg.Weekend < 0 || cfg.Weekend > 1 {
+		return nil, errs.F("weekend fraction should be between 0 and 1")
+	}
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+
+	d := &Dataset{Start: cfg.Start, Countries: cfg.Countries}
+	for _, c := range cfg.Countries {
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		confirmed, recovered, deaths, err := c.cases(cfg.Days)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.RecoveredDays > 0 {
+			for t := cfg.RecoveredDays; t < cfg.Days; t++ {
+				recovered[t] = recovered[cfg.RecoveredDays-1]
+			}
+		}
+		d.Confirmed = append(d.Confirmed, cfg.report(confirmed, rnd))
+		d.Recovered = append(d.Recovered, cfg.report(recovered, rnd))
+		d.Deaths = append(d.Deaths, cfg.report(deaths, rnd))
+	}
+
+	return d, nil
+}
+
+// WriteCSV writes cases of the dataset (e.g. d.Confirmed) in the wide format of the data sources.
+func (d *Dataset) WriteCSV(w io.Writer, cases [][]int) error {
+	if len(cases) != len(d.Countries) {
+		return errs.F("cases of %d countries, instead of %d", len(cases), len(d.Countries))
+	}
+	cw := csv.NewWriter(w)
+	header := []string{"Province/State", "Country/Region", "Lat", "Long"}
+	if len(cases) > 0 {
+		for t := range cases[0] {
+			header = append(header, d.Start.AddDate(0, 0, t).Format(formatToCSV))
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return errs.W(err)
+	}
+	for i, c := range d.Countries {
+		row := []string{
+			c.Province, c.Name,
+			strconv.FormatFloat(c.Lat, 'f', -1, 64),
+			strconv.FormatFloat(c.Long, 'f', -1, 64),
+		}
+		for _, n := range cases[i] {
+			row = append(row, strconv.Itoa(n))
+		}
+		if err := cw.Write(row); err != nil {
+			return errs.W(err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return errs.W(err)
+	}
+	return nil
+}
+
+func (c Country) validate() error {
+	switch {
+	case c.Name == "":
+		return errs.F("country without name")
+	case c.Cases <= 0:
+		return errs.F("%s: cases should be positive", c.Name)
+	case c.Rate <= 0:
+		return errs.F("%s: rate should be positive", c.Name)
+	case c.DimFactor <= 0:
+		return errs.F("%s: dim factor should be positive", c.Name)
+	case c.Closing < 0 || c.Closing > 1:
+		return errs.F("%s: closing fraction should be between 0 and 1", c.Name)
+	case c.Fatality < 0 || c.Fatality > 1:
+		return errs.F("%s: fatality fraction should be between 0 and 1", c.Name)
+	}
+	return nil
+}
+
+// cases actually happened, cumulated by day.
+func (c Country) cases(days int) (confirmed, recovered, deaths []float64, err error) {
+	confirmed = make([]float64, days)
+	recovered = make([]float64, days)
+	deaths = make([]float64, days)
+
+	active := c.Cases
+	confirmed[0] = active
+	for t := 1; t < days; t++ {
+		closed := active * c.Closing
+		target, err := calc.Forecast3D(c.Cases, c.Rate, c.DimFactor, float64(t))
+		if err != nil {
+			return nil, nil, nil, errs.W(err)
+		}
+		confirmed[t] = confirmed[t-1] + math.Max(0, target-active+closed)
+		recovered[t] = recovered[t-1] + closed*(1-c.Fatality)
+		deaths[t] = deaths[t-1] + closed*c.Fatality
+		active = math.Max(target, active-closed)
+	}
+	return
+}
+
+// report cumulative cases, with noise and weekend artifacts on the daily cases.
+func (cfg Config) report(cumulative []float64, rnd *rand.Rand) []int {
+	reported := make([]int, len(cumulative))
+	var (
+		total, last float64
+		delayed     float64
+	)
+	for t, c := range cumulative {
+		c = math.Round(c)
+		daily := c - last
+		last = c
+		if cfg.Noise > 0 {
+			daily *= math.Exp(rnd.NormFloat64()*cfg.Noise - cfg.Noise*cfg.Noise/2)
+		}
+		switch cfg.Start.AddDate(0, 0, t).Weekday() {
+		case time.Saturday, time.Sunday:
+			late := math.Round(daily * cfg.Weekend)
+			delayed += late
+			daily -= late
+		case time.Monday:
+			daily += delayed
+			delayed = 0
+		}
+		total += math.Round(daily)
+		reported[t] = int(total)
+	}
+	return reported
+}
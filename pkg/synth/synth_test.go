@@ -0,0 +1,127 @@
+package synth_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/database"
+	"github.com/jsidew/covid/pkg/synth"
+)
+
+var (
+	start   = time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+	country = synth.Country{
+		Name: "Atlantis", Lat: 31, Long: -24,
+		Cases: 1000, Rate: 1.2, DimFactor: 0.995,
+		Closing: 0.07, Fatality: 0.1,
+	}
+)
+
+func Test(t *testing.T) {
+	const days, p, q = 40, 7, 14
+
+	d, err := synth.Generate(synth.Config{Start: start, Days: days, Countries: []synth.Country{country}})
+	require.NoError(t, err, "error")
+	require.Len(t, d.Confirmed, 1, "countries")
+	require.Len(t, d.Confirmed[0], days, "days")
+
+	active := func(t int) float64 {
+		return float64(d.Confirmed[0][t] - d.Recovered[0][t] - d.Deaths[0][t])
+	}
+	now := days - 1
+
+	// the spread rate over the last p days is the average rate of the period
+	r, err := calc.Rate(active(now-p), active(now), p)
+	require.NoError(t, err, "rate")
+	expected := country.Rate * math.Pow(country.DimFactor, float64(now)-float64(p-1)/2)
+	assert.InDelta(t, expected, r, 0.001, "rate")
+
+	// the rates over p and q days are centered (q-p)/2 days apart,
+	// so the rate of rates of the VCS is the square root of the dim factor
+	s, err := calc.Rate(active(now-q), active(now), q)
+	require.NoError(t, err, "comparison rate")
+	x, err := calc.Rate(s, r, q-p)
+	require.NoError(t, err, "rate of rates")
+	assert.InDelta(t, country.DimFactor, x*x, 0.0001, "dim factor")
+
+	for i := 1; i < days; i++ {
+		assert.True(t, d.Confirmed[0][i] >= d.Confirmed[0][i-1], "cumulative confirmed")
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	cfg := synth.Config{
+		Start: start, Days: 30, Countries: []synth.Country{country},
+		Noise: 0.2, Weekend: 0.5, RecoveredDays: 20, Seed: 1,
+	}
+	d, err := synth.Generate(cfg)
+	require.NoError(t, err, "error")
+	again, err := synth.Generate(cfg)
+	require.NoError(t, err, "error")
+	assert.Equal(t, d, again, "same seed")
+
+	assert.Equal(t, d.Recovered[0][19], d.Recovered[0][29], "recovered not reported")
+	assert.True(t, d.Recovered[0][19] > d.Recovered[0][18], "recovered reported")
+
+	sat := 1
+	for start.AddDate(0, 0, sat).Weekday() != time.Saturday {
+		sat++
+	}
+	clean, err := synth.Generate(synth.Config{Start: start, Days: 30, Countries: []synth.Country{country}, Weekend: 0.5})
+	require.NoError(t, err, "error")
+	unreported := func(d *synth.Dataset) int { return d.Confirmed[0][sat+1] - d.Confirmed[0][sat-1] }
+	noWeekend, err := synth.Generate(synth.Config{Start: start, Days: 30, Countries: []synth.Country{country}})
+	require.NoError(t, err, "error")
+	assert.InDelta(t, unreported(noWeekend)/2, unreported(clean), 1, "weekend cases")
+	assert.InDelta(t, noWeekend.Confirmed[0][sat+2], clean.Confirmed[0][sat+2], 1, "monday cases")
+
+	_, err = synth.Generate(synth.Config{Days: 10, Countries: []synth.Country{{Name: "Nowhere", Cases: 10}}})
+	assert.EqualError(t, err, "synth: Nowhere: rate should be positive", "invalid country")
+}
+
+func TestDatabase(t *testing.T) {
+	d, err := synth.Generate(synth.Config{Start: start, Days: 20, Countries: []synth.Country{
+		country,
+		{Name: "Lemuria", Province: "North", Cases: 50, Rate: 1.1, DimFactor: 1, Closing: 0.05},
+	}})
+	require.NoError(t, err, "error")
+
+	dir, err := ioutil.TempDir("", "synth-")
+	require.NoError(t, err, "error")
+	defer os.RemoveAll(dir)
+	for name, cases := range map[string][][]int{
+		"confirmed": d.Confirmed,
+		"recovered": d.Recovered,
+		"dead":      d.Deaths,
+	} {
+		b := bytes.Buffer{}
+		require.NoError(t, d.WriteCSV(&b, cases), "write")
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".csv"), b.Bytes(), 0644), "write file")
+	}
+
+	db := database.New("", dir, time.Hour)
+	db.Set("confirmed", "", database.Total)
+	db.Set("recovered", "", database.Subtract)
+	db.Set("dead", "", database.Subtract)
+
+	latest, err := db.Latest()
+	require.NoError(t, err, "error")
+	assert.Equal(t, start.AddDate(0, 0, 19), latest, "latest")
+
+	countries, err := db.Countries()
+	require.NoError(t, err, "error")
+	assert.Equal(t, []string{"Atlantis", "Lemuria"}, countries, "countries")
+
+	cases, err := db.ActiveCases("Lemuria/North", latest)
+	require.NoError(t, err, "error")
+	assert.Equal(t, d.Confirmed[1][19]-d.Recovered[1][19]-d.Deaths[1][19], cases, "active cases")
+}
@@ -15,7 +15,13 @@ const (
 	Name TemplateName = "default"
 
 	// Template default content.
-	Template = `{{ .Country }}:
+	Template = `{{- $kind := "active" }}{{ $units := "cases" }}{{ $unit := "case" }}
+{{- if eq .Metric "deaths" }}{{ $kind = "daily" }}{{ $units = "deaths" }}{{ $unit = "death" }}
+{{- else if eq .Metric "confirmed" }}{{ $kind = "daily" }}{{ end }}
+{{- $event := false }}{{ range .Events }}{{ if not (.Date.Before $.Current.Since) }}{{ $event = . }}{{ end }}{{ end -}}
+{{ .Country }}:
+{{- if .Status.InsufficientData }} insufficient data to estimate the spread. #Covid_19 {{ print "en" .Current.Cases }} {{ if .Current.Estimated }}estimated {{ end }}{{ $kind }} {{ $units }}, as of {{ fmtdate "2 Jan 2006" .Updated }}
+{{- else }}
 {{-      if eq .Status.Score 1 }} resolving
 {{- else if eq .Status.Score 2 }} resolving slowly
 {{- else if eq .Status.Score 3 }} under control
@@ -24,16 +30,20 @@ const (
 {{- else if eq .Status.Score 6 }} loosing control
 {{- else }} out of control
 {{- end -}}
-. #Covid_19 active cases {{ if lt .Current.Rate 1.0 }}dropping{{ else }}growing{{ end }} daily by {{ printf "en" "%.2f" .Current.Rate }}
+{{- if ne .Status.ScoreLow .Status.ScoreHigh }} (score {{ .Status.ScoreLow }}-{{ .Status.ScoreHigh }}){{ end -}}
+. #Covid_19 {{ $kind }} {{ $units }} {{ if lt .Current.Rate 1.0 }}dropping{{ else }}growing{{ end }} daily by {{ printf "en" "%.2f" .Current.Rate }}{{ if .Current.Deseasonalized }} (weekday-adjusted){{ end }}
 {{- if .Status.Improving -}}
 , w/dim factor of {{ printf "en" "%.3f" .Comparison.RateOfRates }}
 {{- end -}}
-. {{ print "en" .Current.Cases }} active cases, as of {{ fmtdate "2 Jan 2006" .Updated }}. Projection:
-{{- if .Status.Improving }} recovering will start in {{ printf "en" "%.0f" .Recovery.DaysToStart }} days with a peak of {{ printf "en" "%.0f" .Recovery.PeakCases }} cases before it
-{{- else }} {{ printf "en" "%.0f" .Forecast.Cases }} cases in {{ print "en" .Forecast.Days }} days
+{{- with $event }} ({{ .Label }} on {{ fmtdate "2 Jan" .Date }}){{ end -}}
+. {{ print "en" .Current.Cases }} {{ if .Current.Estimated }}estimated {{ end }}{{ $kind }} {{ $units }}, as of {{ fmtdate "2 Jan 2006" .Updated }}. Projection:
+{{- if .Status.Improving }} recovering will start in {{ printf "en" "%.0f" .Recovery.DaysToStart }} days with a peak of {{ printf "en" "%.0f" .Recovery.PeakCases }} {{ $units }}
+{{- if finite .Recovery.PeakCasesHigh }} ({{ printf "en" "%.0f" .Recovery.PeakCasesLow }}-{{ printf "en" "%.0f" .Recovery.PeakCasesHigh }}){{ end }} before it
+{{- else }} {{ printf "en" "%.0f" .Forecast.Cases }} {{ $units }} in {{ print "en" .Forecast.Days }} days
 {{- end -}}
 {{- if .Status.Resolving -}}
-; only 1 active case left in {{ printf "en" "%.0f" .Recovery.DaysTo1 }} days
+; only 1 {{ $kind }} {{ $unit }} left in {{ printf "en" "%.0f" .Recovery.DaysTo1 }} days
+{{- end -}}
 {{- end -}}
 . @jsidew [src: https://a.jsidew.net/covid]
 `
@@ -49,4 +59,7 @@ var funcMap = template.FuncMap{
 	"fmtdate": func(layout string, t time.Time) string {
 		return t.Format(layout)
 	},
+	"label":    Label,
+	"doubling": Doubling,
+	"finite":   Finite,
 }
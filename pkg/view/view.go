@@ -2,8 +2,10 @@
 package view
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +13,8 @@ import (
 	"time"
 
 	"github.com/jsidew/covid/internal/errors"
+	"github.com/jsidew/covid/pkg/calc"
+	"github.com/jsidew/covid/pkg/events"
 )
 
 const (
@@ -36,33 +40,113 @@ const (
 	OutOfControl
 )
 
+var labels = []string{
+	0:                  "insufficient data",
+	Resolving:          "resolving",
+	ResolvingSlowly:    "resolving slowly",
+	UnderControl:       "under control",
+	BarelyUnderControl: "barely under control",
+	HardToControl:      "hard to control",
+	LoosingControl:     "loosing control",
+	OutOfControl:       "out of control",
+}
+
 func init() {
 	errors.Prefix = "view"
 }
 
+// Label of a status score (e.g. "under control" for UnderControl, "insufficient data" for 0).
+func Label(score uint8) string {
+	if int(score) >= len(labels) || labels[score] == "" {
+		return "unknown"
+	}
+	return labels[score]
+}
+
+/*
+Doubling describes the days that a number takes to double at a spread rate,
+or to halve if the rate is lower than 1, while the rate varies at the optional rate of rates:
+e.g. "doubling every 7 days", "halving every 12 days", "stable" at rate 1,
+or "never doubling" if the rate drops to 1 before doubling.
+*/
+func Doubling(rate float64, rateOfRates ...float64) string {
+	x := 1.0
+	if len(rateOfRates) > 0 {
+		x = rateOfRates[0]
+	}
+	verb := "doubling"
+	if rate < 1 {
+		verb = "halving"
+	}
+	days, err := calc.DoublingTime(rate, x)
+	switch {
+	case err == calc.ErrUnitRate:
+		return "stable"
+	case err != nil:
+		return "never " + verb
+	case math.Round(days) <= 1:
+		return verb + " every day"
+	}
+	return fmt.Sprintf("%s every %.0f days", verb, days)
+}
+
+// Finite tells whether a number is neither infinite nor NaN (e.g. the high bound of a projection).
+func Finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
 // TemplateName is a name of a template
 type TemplateName string
 
 // View of a template.
 // Use View's fields to pass information to your template.
 type View struct {
-	Country string
-	Updated time.Time
+	Country    string
+	Updated    time.Time
+	Confidence float64
+
+	// Metric of the spread: "active" (cases), "deaths" or "confirmed" (daily, averaged over a week).
+	Metric string
 
 	Status struct {
-		Score     uint8
-		Resolving bool
-		Improving bool
+		Score            uint8
+		Resolving        bool
+		Improving        bool
+		InsufficientData bool
+
+		ScoreLow, ScoreHigh uint8
+		Stable              bool
 	}
 
 	Current struct {
-		Rate  float64
-		Cases int
+		// Since is the first day of the estimate.
+		Since time.Time
+
+		Rate      float64
+		RateLow   float64
+		RateHigh  float64
+		Method    string
+		StdErr    float64
+		R2        float64
+		Cases     int
+		Estimated bool
+
+		Rt, RtLow, RtHigh float64
+
+		DoublingTime float64
+		Halving      bool
+
+		Deseasonalized bool
+		Seasonality    [7]float64
 	}
 
 	Comparison struct {
 		Rate        float64
+		StdErr      float64
+		R2          float64
 		RateOfRates float64
+
+		RateOfRatesLow, RateOfRatesHigh float64
 	}
 
 	Recovery struct {
@@ -70,14 +154,34 @@ type View struct {
 		DaysToStart float64
 		DaysToPeak  float64
 		PeakCases   float64
+
+		DaysToStartLow, DaysToStartHigh float64
+		PeakCasesLow, PeakCasesHigh     float64
 	}
 
 	Forecast struct {
 		Growth string
 		Cases  float64
 		Days   int
+
+		CasesLow, CasesHigh float64
+		DoublingTime        float64
 	}
 
+	Metrics struct {
+		Days int
+
+		NewCases, NewDeaths               int
+		NewCasesAverage, NewDeathsAverage float64
+		NewCasesChange, NewDeathsChange   float64
+
+		CaseFatality float64
+		FatalityLag  int
+	}
+
+	// Events of the country up to the update, sorted by date (e.g. lockdowns).
+	Events events.Events
+
 	tpl *template.Template
 }
 
@@ -90,7 +194,10 @@ If selected is empty, the default template (Template) will be used.
 The following custom template functions can be used:
 	- print LANG ARGUMENTS, like fmt.Print, but formatted according to local LANG (see doc for golang.org/x/text/message);
 	- printf LANG FORMAT ARGUMENTS, like fmt.Printf, but formatted according to local LANG (see doc for golang.org/x/text/message);
-	- fmtdate LAYOUT TIME, like t.Format(LAYOUT), where t is the TIME object.
+	- fmtdate LAYOUT TIME, like t.Format(LAYOUT), where t is the TIME object;
+	- label SCORE, the label of a status score (see Label);
+	- finite NUMBER, if the float64 NUMBER is neither infinite nor NaN (e.g. for the high bounds of projections);
+	- doubling RATE [RATE_OF_RATES], the time to double at the spread RATE varying at RATE_OF_RATES (see Doubling).
 */
 func New(dir string, selected TemplateName) (*View, error) {
 	// create default template file if doesn't exist.
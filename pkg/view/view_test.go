@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jsidew/covid/pkg/events"
 	"github.com/jsidew/covid/pkg/view"
 )
 
@@ -60,6 +61,33 @@ Date: {{ fmtdate "2 Jan 2006" .Updated }}`))
 		assert.Equal(t, `Date: 23 Mar 2020`, rows[2], "final view: fmtdate")
 	})
 
+	t.Run("event of the estimate", func(t *testing.T) {
+		v, err := view.New(env.TmpDir(), "")
+		require.NoError(t, err, "New error")
+		v.Current.Rate = 1.1
+		v.Current.Since = time.Date(2020, 03, 12, 0, 0, 0, 0, time.UTC)
+		v.Events = events.Events{
+			{Country: "Italy", Date: time.Date(2020, 03, 9, 0, 0, 0, 0, time.UTC), Label: "lockdown"},
+		}
+		b := strings.Builder{}
+		require.NoError(t, v.Execute(&b), "View.Execute error")
+		assert.NotContains(t, b.String(), "lockdown", "event before the estimate")
+
+		v.Current.Since = time.Date(2020, 03, 5, 0, 0, 0, 0, time.UTC)
+		b.Reset()
+		require.NoError(t, v.Execute(&b), "View.Execute error")
+		assert.Contains(t, b.String(), "growing daily by 1.10 (lockdown on 9 Mar).", "event of the estimate")
+	})
+
+}
+
+func TestDoubling(t *testing.T) {
+	assert.Equal(t, "doubling every 7 days", view.Doubling(1.104))
+	assert.Equal(t, "halving every 14 days", view.Doubling(0.952))
+	assert.Equal(t, "doubling every day", view.Doubling(2.1))
+	assert.Equal(t, "stable", view.Doubling(1))
+	assert.Equal(t, "doubling every 10 days", view.Doubling(1.104, 0.995))
+	assert.Equal(t, "never doubling", view.Doubling(1.01, 0.9))
 }
 
 type setting struct {